@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/lierbai/web/internal/bytesconv"
 )
@@ -83,3 +84,88 @@ func authorizationHeader(user, password string) string {
 	base := user + ":" + password
 	return "Basic " + base64.StdEncoding.EncodeToString(bytesconv.StringToBytes(base))
 }
+
+// AuthScheme MultiAuth使用的认证方式.
+type AuthScheme interface {
+	// Authenticate 认证请求,成功时返回认证主体(如用户名)和true.
+	Authenticate(c *Context) (principal interface{}, ok bool)
+}
+
+// AuthChallenger 可由AuthScheme选择实现,提供认证失败时该方式的WWW-Authenticate质询(如`Basic realm="api"`).
+type AuthChallenger interface {
+	Challenge() string
+}
+
+// MultiAuth 返回按顺序尝试schemes的认证中间件,任意一种成功即通过,并将认证主体存储到AuthUserKey.
+// 全部失败时返回401并中止,WWW-Authenticate包含所有实现了AuthChallenger的方式的质询(没有时不设置).
+func MultiAuth(schemes ...AuthScheme) HandlerFunc {
+	assert1(len(schemes) > 0, "MultiAuth至少需要一种认证方式")
+	challenges := make([]string, 0, len(schemes))
+	for _, scheme := range schemes {
+		if challenger, ok := scheme.(AuthChallenger); ok {
+			challenges = append(challenges, challenger.Challenge())
+		}
+	}
+	challenge := strings.Join(challenges, ", ")
+
+	return func(c *Context) {
+		for _, scheme := range schemes {
+			if principal, ok := scheme.Authenticate(c); ok {
+				c.Set(AuthUserKey, principal)
+				return
+			}
+		}
+		if challenge != "" {
+			c.Header("WWW-Authenticate", challenge)
+		}
+		c.AbortWithStatus(http.StatusUnauthorized)
+	}
+}
+
+type basicScheme struct {
+	pairs authPairs
+	realm string
+}
+
+// BasicScheme 返回使用accounts校验Basic认证的AuthScheme,认证主体为用户名.
+func BasicScheme(accounts Accounts, realm string) AuthScheme {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	return basicScheme{pairs: processAccounts(accounts), realm: realm}
+}
+
+func (s basicScheme) Authenticate(c *Context) (interface{}, bool) {
+	user, found := s.pairs.searchCredential(c.requestHeader("Authorization"))
+	return user, found
+}
+
+func (s basicScheme) Challenge() string {
+	return "Basic realm=" + strconv.Quote(s.realm)
+}
+
+type bearerScheme struct {
+	validate func(token string) (interface{}, bool)
+	realm    string
+}
+
+// BearerScheme 返回校验Bearer令牌的AuthScheme,validate校验令牌并返回认证主体.
+func BearerScheme(realm string, validate func(token string) (interface{}, bool)) AuthScheme {
+	if realm == "" {
+		realm = "Authorization Required"
+	}
+	return bearerScheme{validate: validate, realm: realm}
+}
+
+func (s bearerScheme) Authenticate(c *Context) (interface{}, bool) {
+	const prefix = "Bearer "
+	auth := c.requestHeader("Authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return nil, false
+	}
+	return s.validate(strings.TrimSpace(auth[len(prefix):]))
+}
+
+func (s bearerScheme) Challenge() string {
+	return "Bearer realm=" + strconv.Quote(s.realm)
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMultiAuth(t *testing.T) {
+	type service struct{ Name string }
+	r := New()
+	r.Use(MultiAuth(
+		BasicScheme(Accounts{"alice": "secret"}, "api"),
+		BearerScheme("api", func(token string) (interface{}, bool) {
+			if token == "svc-token" {
+				return service{Name: "billing"}, true
+			}
+			return nil, false
+		}),
+	))
+	var principal interface{}
+	r.GET("/", func(c *Context) {
+		principal = c.MustGet(AuthUserKey)
+		c.Status(http.StatusOK)
+	})
+
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:secret"))
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantPrincipal interface{}
+	}{
+		{"basic", basic, http.StatusOK, "alice"},
+		{"bearer", "Bearer svc-token", http.StatusOK, service{Name: "billing"}},
+		{"bearer lowercase scheme", "bearer svc-token", http.StatusOK, service{Name: "billing"}},
+		{"wrong password", "Basic " + base64.StdEncoding.EncodeToString([]byte("alice:nope")), http.StatusUnauthorized, nil},
+		{"wrong token", "Bearer other", http.StatusUnauthorized, nil},
+		{"missing", "", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		principal = nil
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tt.wantCode || principal != tt.wantPrincipal {
+			t.Errorf("%s: %d principal %v, want %d %v", tt.name, w.Code, principal, tt.wantCode, tt.wantPrincipal)
+		}
+		challenge := w.Header().Get("WWW-Authenticate")
+		if tt.wantCode == http.StatusUnauthorized && challenge != `Basic realm="api", Bearer realm="api"` {
+			t.Errorf("%s: WWW-Authenticate = %q", tt.name, challenge)
+		}
+		if tt.wantCode == http.StatusOK && challenge != "" {
+			t.Errorf("%s: WWW-Authenticate = %q on success", tt.name, challenge)
+		}
+	}
+}
+
+type apiKeyScheme struct{ key string }
+
+func (s apiKeyScheme) Authenticate(c *Context) (interface{}, bool) {
+	return "api-key", c.GetHeader("X-Api-Key") == s.key
+}
+
+func TestMultiAuthSchemeWithoutChallenge(t *testing.T) {
+	r := New()
+	r.Use(MultiAuth(apiKeyScheme{key: "k"}, BearerScheme("api", func(string) (interface{}, bool) { return nil, false })))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, c.MustGet(AuthUserKey).(string)) })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", "k")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "api-key" {
+		t.Errorf("got %d %q, want 200 api-key", w.Code, w.Body.String())
+	}
+
+	w = performRequest(r, http.MethodGet, "/")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Bearer realm="api"` {
+		t.Errorf("got %d WWW-Authenticate %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+
+	only := New()
+	only.Use(MultiAuth(apiKeyScheme{key: "k"}))
+	only.GET("/", func(c *Context) {})
+	w = performRequest(only, http.MethodGet, "/")
+	if _, ok := w.Header()["Www-Authenticate"]; w.Code != http.StatusUnauthorized || ok {
+		t.Errorf("got %d headers %v, want 401 without WWW-Authenticate", w.Code, w.Header())
+	}
+}
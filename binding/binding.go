@@ -46,6 +46,7 @@ var (
 	FormMultipart = formMultipartBinding{}
 	Header        = headerBinding{}
 	JSON          = jsonBinding{}
+	JSONAndQuery  = jsonQueryBinding{}
 	Query         = queryBinding{}
 	Uri           = uriBinding{}
 	XML           = xmlBinding{}
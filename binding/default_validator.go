@@ -1,6 +1,8 @@
 package binding
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -47,3 +49,35 @@ func (v *defaultValidator) lazyinit() {
 		v.validate.SetTagName("binding")
 	})
 }
+
+// FieldErrors 将验证失败的错误转换为 字段名->错误描述 的映射,便于直接渲染到表单.
+// err不是(或不包含)validator.ValidationErrors时返回nil.
+func FieldErrors(err error) map[string]string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	fields := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		if fe.Param() != "" {
+			fields[fe.Field()] = fmt.Sprintf("%s未通过'%s=%s'验证", fe.Field(), fe.Tag(), fe.Param())
+		} else {
+			fields[fe.Field()] = fmt.Sprintf("%s未通过'%s'验证", fe.Field(), fe.Tag())
+		}
+	}
+	return fields
+}
+
+// FieldRules 将验证失败的错误转换为 字段名->未通过的规则(如"required") 的映射.
+// err不是(或不包含)validator.ValidationErrors时返回nil.
+func FieldRules(err error) map[string]string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	rules := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		rules[fe.Field()] = fe.Tag()
+	}
+	return rules
+}
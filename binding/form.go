@@ -45,6 +45,8 @@ func (formMultipartBinding) Name() string {
 	return "multipart/form-data"
 }
 
+// Bind 解析multipart表单并绑定到obj,`form`标签对应上传文件的字段可以是*multipart.FileHeader、multipart.FileHeader
+// 或它们的切片/数组(多文件),未上传的文件字段保持零值.
 func (formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
 	if err := req.ParseMultipartForm(defaultMemory); err != nil {
 		return err
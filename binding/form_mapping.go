@@ -1,6 +1,7 @@
 package binding
 
 import (
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,8 +15,11 @@ import (
 
 var errUnknownType = errors.New("unknown type")
 
+// maxArrayIndex 索引数组语法(key[i])允许的最大索引,避免超大索引导致大量内存分配
+const maxArrayIndex = 1024
+
 func mapUri(ptr interface{}, m map[string][]string) error {
-	return mapFormByTag(ptr, m, "uri")
+	return mappingByPtr(ptr, uriSource(m), "uri")
 }
 
 func mapForm(ptr interface{}, form map[string][]string) error {
@@ -54,6 +58,8 @@ func mapping(value reflect.Value, field reflect.StructField, setter setter, tag
 
 	var vKind = value.Kind()
 
+	// 指针字段只在设置成功后才赋值,参数缺失时保持nil(用于区分缺失与零值).
+	// 参数存在但为空(如"?n=")时分配零值,即"已提供"的零值
 	if vKind == reflect.Ptr {
 		var isNew bool
 		vPtr := value
@@ -135,6 +141,16 @@ func tryToSetValue(value reflect.Value, field reflect.StructField, setter setter
 
 func setByForm(value reflect.Value, field reflect.StructField, form map[string][]string, tagValue string, opt setOptions) (isSetted bool, err error) {
 	vs, ok := form[tagValue]
+	if !ok && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) {
+		if vs, ok, err = indexedValues(form, tagValue); err != nil {
+			return false, err
+		}
+	}
+	if !ok && value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String {
+		if isSetted, err = setBracketMap(value, field, form, tagValue); isSetted || err != nil {
+			return isSetted, err
+		}
+	}
 	if !ok && !opt.isDefaultExists {
 		return false, nil
 	}
@@ -166,7 +182,71 @@ func setByForm(value reflect.Value, field reflect.StructField, form map[string][
 	}
 }
 
+// indexedValues 按索引顺序收集key[0]=a&key[1]=b形式的值,缺失的索引填充空字符串(即零值).
+func indexedValues(form map[string][]string, key string) ([]string, bool, error) {
+	var vs []string
+	for k, v := range form {
+		sub, ok := bracketKey(k, key)
+		if !ok {
+			continue
+		}
+		idx, err := strconv.Atoi(sub)
+		if err != nil || idx < 0 {
+			continue
+		}
+		if idx >= maxArrayIndex {
+			return nil, false, fmt.Errorf("index %d of %q exceeds the limit %d", idx, key, maxArrayIndex)
+		}
+		if idx >= len(vs) {
+			vs = append(vs, make([]string, idx+1-len(vs))...)
+		}
+		if len(v) > 0 {
+			vs[idx] = v[0]
+		}
+	}
+	return vs, vs != nil, nil
+}
+
+// setBracketMap 将key[name]=v形式的值设置到键为字符串的map字段,值按map声明的元素类型转换(如map[string]int).
+func setBracketMap(value reflect.Value, field reflect.StructField, form map[string][]string, key string) (bool, error) {
+	var m reflect.Value
+	for k, v := range form {
+		name, ok := bracketKey(k, key)
+		if !ok || len(v) == 0 {
+			continue
+		}
+		elem := reflect.New(value.Type().Elem()).Elem()
+		if err := setWithProperType(v[0], elem, field); err != nil {
+			return false, err
+		}
+		if !m.IsValid() {
+			m = reflect.MakeMap(value.Type())
+		}
+		m.SetMapIndex(reflect.ValueOf(name).Convert(value.Type().Key()), elem)
+	}
+	if !m.IsValid() {
+		return false, nil
+	}
+	value.Set(m)
+	return true, nil
+}
+
+// bracketKey k为key[sub]形式(sub非空且不嵌套方括号)时返回sub.
+func bracketKey(k, key string) (string, bool) {
+	if len(k) <= len(key)+2 || k[:len(key)] != key || k[len(key)] != '[' || k[len(k)-1] != ']' {
+		return "", false
+	}
+	sub := k[len(key)+1 : len(k)-1]
+	if strings.ContainsAny(sub, "[]") {
+		return "", false
+	}
+	return sub, true
+}
+
 func setWithProperType(val string, value reflect.Value, field reflect.StructField) error {
+	if ok, err := trySetTextUnmarshaler(val, value); ok {
+		return err
+	}
 	switch value.Kind() {
 	case reflect.Int:
 		return setIntField(val, 0, value)
@@ -214,7 +294,25 @@ func setWithProperType(val string, value reflect.Value, field reflect.StructFiel
 	return nil
 }
 
+// trySetTextUnmarshaler 字段实现了encoding.TextUnmarshaler时调用UnmarshalText设置值.
+// time.Time仍由setTimeField处理,以支持time_format等标签.
+func trySetTextUnmarshaler(val string, value reflect.Value) (bool, error) {
+	if !value.CanAddr() {
+		return false, nil
+	}
+	if _, isTime := value.Interface().(time.Time); isTime {
+		return false, nil
+	}
+	u, ok := value.Addr().Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return false, nil
+	}
+	return true, u.UnmarshalText(bytesconv.StringToBytes(val))
+}
+
+// 数值字段会先去掉val两端的空白,允许"+"前缀(如" 42 "和"+7").
 func setIntField(val string, bitSize int, field reflect.Value) error {
+	val = strings.TrimSpace(val)
 	if val == "" {
 		val = "0"
 	}
@@ -226,6 +324,10 @@ func setIntField(val string, bitSize int, field reflect.Value) error {
 }
 
 func setUintField(val string, bitSize int, field reflect.Value) error {
+	val = strings.TrimSpace(val)
+	if strings.HasPrefix(val, "+") {
+		val = val[1:]
+	}
 	if val == "" {
 		val = "0"
 	}
@@ -248,6 +350,7 @@ func setBoolField(val string, field reflect.Value) error {
 }
 
 func setFloatField(val string, bitSize int, field reflect.Value) error {
+	val = strings.TrimSpace(val)
 	if val == "" {
 		val = "0.0"
 	}
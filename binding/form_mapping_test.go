@@ -0,0 +1,165 @@
+package binding
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMappingPointerFieldsStayNilWhenAbsent(t *testing.T) {
+	type filter struct {
+		N    *int    `form:"n"`
+		Name *string `form:"name"`
+	}
+	tests := []struct {
+		query   string
+		n       *int
+		hasName bool
+	}{
+		{"n=5&name=x", intPtr(5), true},
+		{"", nil, false},
+		{"n=&name=", intPtr(0), true},
+	}
+	for _, tt := range tests {
+		for _, b := range []Binding{Query, Form} {
+			var f filter
+			req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			if err := b.Bind(req, &f); err != nil {
+				t.Fatalf("%s %q: %v", b.Name(), tt.query, err)
+			}
+			if (f.N == nil) != (tt.n == nil) || (f.N != nil && *f.N != *tt.n) {
+				t.Errorf("%s %q: N = %v, want %v", b.Name(), tt.query, f.N, tt.n)
+			}
+			if (f.Name != nil) != tt.hasName {
+				t.Errorf("%s %q: Name = %v, want set = %v", b.Name(), tt.query, f.Name, tt.hasName)
+			}
+		}
+	}
+
+	var f filter
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"name": {"y"}}.Encode()))
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	if err := FormPost.Bind(req, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.N != nil || f.Name == nil || *f.Name != "y" {
+		t.Errorf("post form: N = %v, Name = %v", f.N, f.Name)
+	}
+}
+
+func TestMappingNumericWhitespaceAndSign(t *testing.T) {
+	type amounts struct {
+		Int   int     `form:"int"`
+		Uint  uint    `form:"uint"`
+		Float float64 `form:"float"`
+	}
+	tests := []struct {
+		value   string
+		want    amounts
+		wantErr bool
+	}{
+		{" 42 ", amounts{42, 42, 42}, false},
+		{"+7", amounts{7, 7, 7}, false},
+		{"\t+1.5\n", amounts{}, true}, // int不接受小数
+		{"4x", amounts{}, true},
+	}
+	for _, tt := range tests {
+		var a amounts
+		form := url.Values{"int": {tt.value}, "uint": {tt.value}, "float": {tt.value}}
+		err := mapForm(&a, form)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && a != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.value, a, tt.want)
+		}
+	}
+
+	var signed struct {
+		Int   int     `form:"int"`
+		Float float64 `form:"float"`
+	}
+	if err := mapForm(&signed, url.Values{"int": {" -3 "}, "float": {" +1.5 "}}); err != nil {
+		t.Fatal(err)
+	}
+	if signed.Int != -3 || signed.Float != 1.5 {
+		t.Errorf("signed = %+v, want {Int:-3 Float:1.5}", signed)
+	}
+}
+
+// money 以"1.50 USD"格式绑定的自定义类型.
+type money struct {
+	Cents    int64
+	Currency string
+}
+
+func (m *money) UnmarshalText(text []byte) error {
+	var units, cents int64
+	if _, err := fmt.Sscanf(string(text), "%d.%02d %s", &units, &cents, &m.Currency); err != nil {
+		return fmt.Errorf("无效的金额 %q: %v", text, err)
+	}
+	m.Cents = units*100 + cents
+	return nil
+}
+
+func TestMappingTextUnmarshaler(t *testing.T) {
+	type order struct {
+		Price   money  `form:"price"`
+		Deposit *money `form:"deposit"`
+	}
+	var o order
+	req := httptest.NewRequest(http.MethodGet, "/?"+url.Values{"price": {"1.50 USD"}, "deposit": {"0.25 EUR"}}.Encode(), nil)
+	if err := Query.Bind(req, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.Price != (money{150, "USD"}) {
+		t.Errorf("Price = %+v, want {150 USD}", o.Price)
+	}
+	if o.Deposit == nil || *o.Deposit != (money{25, "EUR"}) {
+		t.Errorf("Deposit = %+v, want {25 EUR}", o.Deposit)
+	}
+
+	if err := mapForm(&o, url.Values{"price": {"lots"}}); err == nil || !strings.Contains(err.Error(), "无效的金额") {
+		t.Errorf("bad input: err = %v, want the UnmarshalText error", err)
+	}
+}
+
+func TestMappingBracketMapValueTypes(t *testing.T) {
+	type search struct {
+		Filter map[string]int     `form:"filter"`
+		Labels map[string]string  `form:"labels"`
+		Weight map[string]float64 `form:"weight"`
+	}
+	var s search
+	req := httptest.NewRequest(http.MethodGet, "/?filter[min]=1&filter[max]=10&labels[env]=prod&weight[a]=0.5&other=x", nil)
+	if err := Query.Bind(req, &s); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"min": 1, "max": 10}; !reflect.DeepEqual(s.Filter, want) {
+		t.Errorf("Filter = %v, want %v", s.Filter, want)
+	}
+	if want := map[string]string{"env": "prod"}; !reflect.DeepEqual(s.Labels, want) {
+		t.Errorf("Labels = %v, want %v", s.Labels, want)
+	}
+	if want := map[string]float64{"a": 0.5}; !reflect.DeepEqual(s.Weight, want) {
+		t.Errorf("Weight = %v, want %v", s.Weight, want)
+	}
+
+	var bad search
+	req = httptest.NewRequest(http.MethodGet, "/?filter[min]=one", nil)
+	if err := Query.Bind(req, &bad); err == nil {
+		t.Errorf("filter[min]=one: err = nil, Filter = %v", bad.Filter)
+	}
+
+	var empty search
+	if err := mapForm(&empty, url.Values{"filter[a][b]": {"1"}}); err != nil || empty.Filter != nil {
+		t.Errorf("nested brackets: Filter = %v, err = %v, want nil map", empty.Filter, err)
+	}
+}
+
+func intPtr(n int) *int { return &n }
@@ -44,3 +44,28 @@ func decodeJSON(r io.Reader, obj interface{}) error {
 	}
 	return validate(obj)
 }
+
+type jsonQueryBinding struct{}
+
+func (jsonQueryBinding) Name() string {
+	return "json_query"
+}
+
+// Bind 先将查询参数映射到obj,再将JSON请求体解码到obj(请求体中的字段覆盖查询参数),最后执行一次结构验证.
+// 请求体为空时只使用查询参数.
+func (jsonQueryBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil {
+		return fmt.Errorf("invalid request")
+	}
+	if err := mapForm(obj, req.URL.Query()); err != nil {
+		return err
+	}
+	if req.Body == nil {
+		return validate(obj)
+	}
+	err := decodeJSON(req.Body, obj)
+	if err == io.EOF {
+		return validate(obj)
+	}
+	return err
+}
@@ -0,0 +1,297 @@
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaErrors 请求体不符合JSON Schema时返回的所有违规描述.
+type SchemaErrors []string
+
+func (errs SchemaErrors) Error() string {
+	return strings.Join(errs, "; ")
+}
+
+type jsonSchemaBinding struct {
+	schema   map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// JSONSchema 返回先用schema校验原始请求体,再将其解码到obj(并执行结构标签验证)的绑定.
+// 支持常用的关键字子集: type, enum, const, required, properties, additionalProperties(布尔值), items(单个schema),
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum(数值);
+// 以及不参与校验的注释关键字$schema, $id, $comment, title, description, default, examples.
+// schema不是有效的JSON对象、含有不支持的关键字(如$ref, oneOf, format)、关键字取值无效或pattern无法编译时panic,
+// 避免不支持的约束被静默忽略.
+func JSONSchema(schema []byte) BindingBody {
+	var s map[string]interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		panic("无效的JSON Schema: " + err.Error())
+	}
+	patterns := make(map[string]*regexp.Regexp)
+	if err := checkSchema(s, "$", patterns); err != nil {
+		panic("无效的JSON Schema: " + err.Error())
+	}
+	return jsonSchemaBinding{schema: s, patterns: patterns}
+}
+
+// schemaTypes type关键字可用的类型名.
+var schemaTypes = map[string]bool{
+	"null": true, "boolean": true, "integer": true, "number": true,
+	"string": true, "array": true, "object": true,
+}
+
+// checkSchema 检查schema只使用受支持的关键字且取值有效,并把所有pattern预先编译到patterns.
+func checkSchema(schema map[string]interface{}, path string, patterns map[string]*regexp.Regexp) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := schema[key]
+		switch key {
+		case "$schema", "$id", "$comment", "title", "description", "default", "examples", "const":
+		case "enum":
+			if _, ok := value.([]interface{}); !ok {
+				return fmt.Errorf("%s.enum必须是数组", path)
+			}
+		case "type":
+			names, ok := value.([]interface{})
+			if !ok {
+				names = []interface{}{value}
+			}
+			for _, name := range names {
+				if n, ok := name.(string); !ok || !schemaTypes[n] {
+					return fmt.Errorf("%s.type包含未知的类型%v", path, name)
+				}
+			}
+		case "required":
+			names, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s.required必须是字符串数组", path)
+			}
+			for _, name := range names {
+				if _, ok := name.(string); !ok {
+					return fmt.Errorf("%s.required必须是字符串数组", path)
+				}
+			}
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.properties必须是对象", path)
+			}
+			for name, prop := range props {
+				sub, ok := prop.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%s.properties.%s必须是schema对象", path, name)
+				}
+				if err := checkSchema(sub, path+".properties."+name, patterns); err != nil {
+					return err
+				}
+			}
+		case "additionalProperties":
+			if _, ok := value.(bool); !ok {
+				return fmt.Errorf("%s.additionalProperties仅支持布尔值", path)
+			}
+		case "items":
+			sub, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s.items仅支持单个schema对象", path)
+			}
+			if err := checkSchema(sub, path+".items", patterns); err != nil {
+				return err
+			}
+		case "minItems", "maxItems", "minLength", "maxLength",
+			"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%s.%s必须是数值", path, key)
+			}
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s.pattern必须是字符串", path)
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%s.pattern: %v", path, err)
+			}
+			patterns[pattern] = re
+		default:
+			return fmt.Errorf("%s: 不支持的关键字'%s'", path, key)
+		}
+	}
+	return nil
+}
+
+func (jsonSchemaBinding) Name() string {
+	return "json_schema"
+}
+
+func (b jsonSchemaBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return fmt.Errorf("invalid request")
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return b.BindBody(body, obj)
+}
+
+func (b jsonSchemaBinding) BindBody(body []byte, obj interface{}) error {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return err
+	}
+	var errs SchemaErrors
+	validateSchema(b.schema, b.patterns, doc, "$", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return decodeJSON(bytes.NewReader(body), obj)
+}
+
+// validateSchema 按schema校验v,违规描述追加到errs,path为v在文档中的位置(如$.items[0].name).
+// schema须已经过checkSchema检查,patterns为其预编译的pattern.
+func validateSchema(schema map[string]interface{}, patterns map[string]*regexp.Regexp, v interface{}, path string, errs *SchemaErrors) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && !matchSchemaType(t, v) {
+		fail("类型应为%v,实际为%s", t, schemaTypeOf(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("值必须是%v之一", enum)
+		}
+	}
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, v) {
+		fail("值必须是%v", c)
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, exists := val[name]; !exists {
+						fail("缺少必需的属性'%s'", name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := props[key].(map[string]interface{}); ok {
+				validateSchema(sub, patterns, val[key], path+"."+key, errs)
+			} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				fail("不允许的属性'%s'", key)
+			}
+		}
+	case []interface{}:
+		if n, ok := schemaNumber(schema, "minItems"); ok && float64(len(val)) < n {
+			fail("元素个数不能少于%v", n)
+		}
+		if n, ok := schemaNumber(schema, "maxItems"); ok && float64(len(val)) > n {
+			fail("元素个数不能多于%v", n)
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				validateSchema(items, patterns, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if n, ok := schemaNumber(schema, "minLength"); ok && length < n {
+			fail("长度不能小于%v", n)
+		}
+		if n, ok := schemaNumber(schema, "maxLength"); ok && length > n {
+			fail("长度不能大于%v", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if !patterns[pattern].MatchString(val) {
+				fail("不匹配模式'%s'", pattern)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema, "minimum"); ok && val < n {
+			fail("不能小于%v", n)
+		}
+		if n, ok := schemaNumber(schema, "maximum"); ok && val > n {
+			fail("不能大于%v", n)
+		}
+		if n, ok := schemaNumber(schema, "exclusiveMinimum"); ok && val <= n {
+			fail("必须大于%v", n)
+		}
+		if n, ok := schemaNumber(schema, "exclusiveMaximum"); ok && val >= n {
+			fail("必须小于%v", n)
+		}
+	}
+}
+
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+// matchSchemaType t为类型名或类型名数组,integer要求数值没有小数部分,number也接受integer.
+func matchSchemaType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		actual := schemaTypeOf(v)
+		return actual == t || (t == "number" && actual == "integer")
+	case []interface{}:
+		for _, name := range t {
+			if matchSchemaType(name, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func schemaTypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return reflect.TypeOf(v).String()
+}
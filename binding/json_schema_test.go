@@ -0,0 +1,106 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["name", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2},
+		"age": {"type": "integer", "minimum": 0},
+		"role": {"enum": ["admin", "user"]},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "pattern": "^[a-z]+$"}}
+	}
+}`
+
+type schemaUser struct {
+	Name string   `json:"name"`
+	Age  int      `json:"age"`
+	Role string   `json:"role"`
+	Tags []string `json:"tags"`
+}
+
+func TestJSONSchemaBindingConforming(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"web","age":3,"role":"admin","tags":["go"]}`))
+	var u schemaUser
+	if err := JSONSchema([]byte(userSchema)).Bind(req, &u); err != nil {
+		t.Fatal(err)
+	}
+	want := schemaUser{Name: "web", Age: 3, Role: "admin", Tags: []string{"go"}}
+	if !reflect.DeepEqual(u, want) {
+		t.Errorf("bound %+v, want %+v", u, want)
+	}
+}
+
+func TestJSONSchemaBindingViolations(t *testing.T) {
+	body := `{"name":"w","age":1.5,"role":"root","tags":["ok","Bad","x"],"extra":true}`
+	var u schemaUser
+	err := JSONSchema([]byte(userSchema)).BindBody([]byte(body), &u)
+
+	var errs SchemaErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want SchemaErrors", err)
+	}
+	want := SchemaErrors{
+		"$.age: 类型应为integer,实际为number",
+		"$: 不允许的属性'extra'",
+		"$.name: 长度不能小于2",
+		"$.role: 值必须是[admin user]之一",
+		"$.tags: 元素个数不能多于2",
+		"$.tags[1]: 不匹配模式'^[a-z]+$'",
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("violations =\n%s\nwant\n%s", strings.Join(errs, "\n"), strings.Join(want, "\n"))
+	}
+	if u.Name != "" {
+		t.Errorf("obj was decoded despite violations: %+v", u)
+	}
+
+	err = JSONSchema([]byte(userSchema)).BindBody([]byte(`{"name":"web"}`), &u)
+	if err == nil || err.Error() != "$: 缺少必需的属性'age'" {
+		t.Errorf("missing property: err = %v", err)
+	}
+}
+
+func TestJSONSchemaRejectsUnsupportedSchemas(t *testing.T) {
+	schemas := map[string]string{
+		"$ref":                        `{"$ref": "#/definitions/user"}`,
+		"oneOf":                       `{"properties": {"id": {"oneOf": [{"type": "string"}, {"type": "integer"}]}}}`,
+		"anyOf":                       `{"anyOf": [{"type": "string"}]}`,
+		"allOf":                       `{"allOf": [{"type": "string"}]}`,
+		"not":                         `{"not": {"type": "string"}}`,
+		"format":                      `{"items": {"type": "string", "format": "email"}}`,
+		"draft-4 exclusiveMinimum":    `{"minimum": 0, "exclusiveMinimum": true}`,
+		"unknown type":                `{"type": "int"}`,
+		"tuple items":                 `{"items": [{"type": "string"}]}`,
+		"additionalProperties schema": `{"additionalProperties": {"type": "string"}}`,
+		"invalid pattern":             `{"properties": {"name": {"pattern": "[a-"}}}`,
+	}
+	for name, schema := range schemas {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: JSONSchema did not panic", name)
+				}
+			}()
+			JSONSchema([]byte(schema))
+		}()
+	}
+}
+
+func TestJSONSchemaAllowsAnnotations(t *testing.T) {
+	schema := `{"$schema": "http://json-schema.org/draft-07/schema#", "title": "user", "description": "d",
+		"properties": {"name": {"type": "string", "default": "web", "examples": ["web"], "$comment": "c"}}}`
+	var u schemaUser
+	if err := JSONSchema([]byte(schema)).BindBody([]byte(`{"name":"web"}`), &u); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,57 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type listRequest struct {
+	Page     int    `form:"page" json:"page"`
+	PageSize int    `form:"page_size" json:"page_size" binding:"max=100"`
+	Keyword  string `form:"keyword" json:"keyword"`
+	Status   string `json:"status"`
+}
+
+func TestJSONAndQueryBinding(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/items?page=2&page_size=20&keyword=query",
+		strings.NewReader(`{"keyword":"body","status":"open"}`))
+	var r listRequest
+	if err := JSONAndQuery.Bind(req, &r); err != nil {
+		t.Fatal(err)
+	}
+	want := listRequest{Page: 2, PageSize: 20, Keyword: "body", Status: "open"}
+	if r != want {
+		t.Errorf("bound %+v, want %+v", r, want)
+	}
+}
+
+func TestJSONAndQueryBindingEmptyBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items?page=3", nil)
+	var r listRequest
+	if err := JSONAndQuery.Bind(req, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Page != 3 {
+		t.Errorf("Page = %d, want 3 from the query", r.Page)
+	}
+}
+
+func TestJSONAndQueryBindingErrors(t *testing.T) {
+	tests := []struct {
+		target, body string
+	}{
+		{"/items?page=x", `{}`},
+		{"/items", `{"page":`},
+		{"/items?page_size=500", `{}`},  // 查询参数验证失败
+		{"/items", `{"page_size":500}`}, // 请求体验证失败
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+		var r listRequest
+		if err := JSONAndQuery.Bind(req, &r); err == nil {
+			t.Errorf("%s %s: err = nil, want error", tt.target, tt.body)
+		}
+	}
+}
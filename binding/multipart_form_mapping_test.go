@@ -0,0 +1,82 @@
+package binding
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newMultipartRequest 构造包含表单字段和上传文件(字段名->文件名->内容)的multipart请求.
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string]map[string]string) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for key, value := range values {
+		if err := mw.WriteField(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for field, named := range files {
+		for name, content := range named {
+			fw, err := mw.CreateFormFile(field, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			io.WriteString(fw, content) // nolint: errcheck
+		}
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func readFileHeader(t *testing.T, fh *multipart.FileHeader) string {
+	t.Helper()
+	f, err := fh.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	return string(data)
+}
+
+func TestFormMultipartBindingFileFields(t *testing.T) {
+	var form struct {
+		Name        string                  `form:"name"`
+		Avatar      *multipart.FileHeader   `form:"avatar"`
+		Attachments []*multipart.FileHeader `form:"attachments"`
+		Missing     *multipart.FileHeader   `form:"missing"`
+	}
+	req := newMultipartRequest(t, map[string]string{"name": "alice"}, map[string]map[string]string{
+		"avatar":      {"me.png": "png-data"},
+		"attachments": {"a.txt": "first", "b.txt": "second"},
+	})
+	if err := FormMultipart.Bind(req, &form); err != nil {
+		t.Fatalf("Bind error: %v", err)
+	}
+
+	if form.Name != "alice" {
+		t.Errorf("Name = %q", form.Name)
+	}
+	if form.Avatar == nil || form.Avatar.Filename != "me.png" || readFileHeader(t, form.Avatar) != "png-data" {
+		t.Errorf("Avatar = %+v", form.Avatar)
+	}
+	if len(form.Attachments) != 2 {
+		t.Fatalf("Attachments = %d files, want 2", len(form.Attachments))
+	}
+	got := map[string]string{}
+	for _, fh := range form.Attachments {
+		got[fh.Filename] = readFileHeader(t, fh)
+	}
+	if got["a.txt"] != "first" || got["b.txt"] != "second" {
+		t.Errorf("Attachments contents = %v", got)
+	}
+	if form.Missing != nil {
+		t.Errorf("Missing = %+v, want nil", form.Missing)
+	}
+}
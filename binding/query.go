@@ -8,6 +8,7 @@ func (queryBinding) Name() string {
 	return "query"
 }
 
+// Bind 将查询参数映射到obj(跳过`form:"-"`的字段)后执行结构验证,切片|map字段可以使用`binding:"dive,..."`逐元素验证.
 func (queryBinding) Bind(req *http.Request, obj interface{}) error {
 	values := req.URL.Query()
 	if err := mapForm(obj, values); err != nil {
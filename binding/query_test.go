@@ -0,0 +1,89 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestQueryBindingRequiredPointerDistinguishesAbsentFromZero(t *testing.T) {
+	type page struct {
+		Page *int `form:"page" binding:"required"`
+	}
+
+	var absent page
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	if err := Query.Bind(req, &absent); err == nil {
+		t.Errorf("absent page bound without error: %v", absent.Page)
+	}
+
+	var zero page
+	req = httptest.NewRequest(http.MethodGet, "/items?page=0", nil)
+	if err := Query.Bind(req, &zero); err != nil {
+		t.Fatalf("page=0 error: %v", err)
+	}
+	if zero.Page == nil || *zero.Page != 0 {
+		t.Errorf("page=0 bound to %v, want pointer to 0", zero.Page)
+	}
+}
+
+func TestQueryBindingSkipTagAndDive(t *testing.T) {
+	type search struct {
+		Q      string   `form:"q"`
+		Secret string   `form:"-"`
+		Tags   []string `form:"tags" binding:"dive,required"`
+	}
+
+	var s search
+	req := httptest.NewRequest(http.MethodGet, "/?q=go&Secret=leak&-=leak&tags=a&tags=b", nil)
+	if err := Query.Bind(req, &s); err != nil {
+		t.Fatalf("Bind error: %v", err)
+	}
+	if s.Q != "go" || s.Secret != "" || len(s.Tags) != 2 {
+		t.Errorf("bound %+v, want skipped Secret and two tags", s)
+	}
+
+	s = search{}
+	req = httptest.NewRequest(http.MethodGet, "/?tags=a&tags=", nil)
+	err := Query.Bind(req, &s)
+	if err == nil {
+		t.Fatal("empty tag element passed dive,required")
+	}
+	if rules := FieldRules(err); rules["Tags[1]"] != "required" {
+		t.Errorf("FieldRules = %v, want Tags[1]: required", rules)
+	}
+}
+
+func TestQueryBindingIndexedArrays(t *testing.T) {
+	type tags struct {
+		Tags []string `form:"tags"`
+		IDs  []int    `form:"ids"`
+	}
+	tests := []struct {
+		query string
+		tags  []string
+		ids   []int
+	}{
+		{"tags=a&tags=b&ids=1&ids=2", []string{"a", "b"}, []int{1, 2}},
+		{"tags[0]=a&tags[1]=b", []string{"a", "b"}, nil},
+		{"tags[2]=c&tags[0]=a&tags[1]=b", []string{"a", "b", "c"}, nil},
+		{"tags[1]=b&ids[2]=7", []string{"", "b"}, []int{0, 0, 7}},
+	}
+	for _, tt := range tests {
+		var got tags
+		req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		if err := Query.Bind(req, &got); err != nil {
+			t.Fatalf("%q: %v", tt.query, err)
+		}
+		if !reflect.DeepEqual(got.Tags, tt.tags) || !reflect.DeepEqual(got.IDs, tt.ids) {
+			t.Errorf("%q: bound %+v, want tags %v ids %v", tt.query, got, tt.tags, tt.ids)
+		}
+	}
+
+	var got tags
+	req := httptest.NewRequest(http.MethodGet, "/?tags[5000]=x", nil)
+	if err := Query.Bind(req, &got); err == nil {
+		t.Errorf("index above the limit bound without error: %v", got.Tags)
+	}
+}
@@ -1,5 +1,10 @@
 package binding
 
+import (
+	"reflect"
+	"strings"
+)
+
 type uriBinding struct{}
 
 func (uriBinding) Name() string {
@@ -12,3 +17,23 @@ func (uriBinding) BindUri(m map[string][]string, obj interface{}) error {
 	}
 	return validate(obj)
 }
+
+type uriSource map[string][]string
+
+var _ setter = uriSource(nil)
+
+// TrySet 尝试按路径参数设置值.切片字段按'/'拆分参数值(如捕获所有参数*filepath).
+func (us uriSource) TrySet(value reflect.Value, field reflect.StructField, tagValue string, opt setOptions) (isSetted bool, err error) {
+	if vs, ok := us[tagValue]; ok && value.Kind() == reflect.Slice {
+		segments := make([]string, 0, len(vs))
+		for _, v := range vs {
+			for _, seg := range strings.Split(v, "/") {
+				if seg != "" {
+					segments = append(segments, seg)
+				}
+			}
+		}
+		return setByForm(value, field, map[string][]string{tagValue: segments}, tagValue, opt)
+	}
+	return setByForm(value, field, us, tagValue, opt)
+}
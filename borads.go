@@ -1,9 +1,10 @@
 package web
 
 import (
+	"fmt"
+	"mime"
 	"net/http"
 	"path"
-	"regexp"
 	"strings"
 )
 
@@ -19,6 +20,7 @@ type IRoutes interface {
 
 	Handle(string, string, ...HandlerFunc) IRoutes
 	Any(string, ...HandlerFunc) IRoutes
+	Match([]string, string, ...HandlerFunc) IRoutes
 	GET(string, ...HandlerFunc) IRoutes
 	POST(string, ...HandlerFunc) IRoutes
 	DELETE(string, ...HandlerFunc) IRoutes
@@ -28,8 +30,10 @@ type IRoutes interface {
 	HEAD(string, ...HandlerFunc) IRoutes
 
 	StaticFile(string, string) IRoutes
+	StaticFileFS(string, string, http.FileSystem) IRoutes
 	Static(string, string) IRoutes
 	StaticFS(string, http.FileSystem) IRoutes
+	StaticFSAliases([]string, http.FileSystem) IRoutes
 }
 
 // Boarder 在内部用于配置路由器,Boarder前缀和handlers数组(中间件)相关联.
@@ -38,6 +42,7 @@ type Boarder struct {
 	basePath string
 	centre   *Centre
 	root     bool
+	host     string // Host()创建的跳板及其子跳板的host pattern
 }
 
 var _ IBoarder = &Boarder{}
@@ -48,12 +53,33 @@ func (boarder *Boarder) Use(middleware ...HandlerFunc) IRoutes {
 	return boarder.returnObj()
 }
 
+// UseWhen 给跳板添加只在pred返回true时执行的中间件,pred为false时直接执行后续处理程序.
+// router.UseWhen(func(c *web.Context) bool { return !strings.HasPrefix(c.Request.URL.Path, "/public") }, auth)
+func (boarder *Boarder) UseWhen(pred func(*Context) bool, middleware ...HandlerFunc) IRoutes {
+	return boarder.Use(conditional(pred, middleware)...)
+}
+
+// conditional 将middleware中的每个中间件封装为只在pred返回true时执行.
+func conditional(pred func(*Context) bool, middleware HandlersChain) HandlersChain {
+	wrapped := make(HandlersChain, len(middleware))
+	for i, handler := range middleware {
+		handler := handler
+		wrapped[i] = func(c *Context) {
+			if pred(c) {
+				handler(c)
+			}
+		}
+	}
+	return wrapped
+}
+
 // Board 创建新的跳板. 您应该添加具有公共中间件或相同路径前缀的所有路由.
 func (boarder *Boarder) Board(relativePath string, handlers ...HandlerFunc) *Boarder {
 	return &Boarder{
 		Handlers: boarder.combineHandlers(handlers),
 		basePath: boarder.calculateAbsolutePath(relativePath),
 		centre:   boarder.centre,
+		host:     boarder.host,
 	}
 }
 
@@ -62,22 +88,30 @@ func (boarder *Boarder) BasePath() string {
 	return boarder.basePath
 }
 
-func (boarder *Boarder) handle(httpMethod, relativePath string, handlers HandlersChain) IRoutes {
+func (boarder *Boarder) handle(httpMethod, relativePath string, handlers HandlersChain, opts ...RouteOption) IRoutes {
 	absolutePath := boarder.calculateAbsolutePath(relativePath)
 	handlers = boarder.combineHandlers(handlers)
-	boarder.centre.addRoute(httpMethod, absolutePath, handlers)
+	if boarder.host != "" {
+		opts = append(opts, onHost(boarder.host))
+	}
+	boarder.centre.addRoute(httpMethod, absolutePath, handlers, opts...)
 	return boarder.returnObj()
 }
 
 // Handle 使用给定的路径和方法注册新的handle和中间件.(批量加载)
 // 最后handle才是真正的处理程序,其他应该是公共中间件.允许使用不常用|非标准化|自定义的方法(如,与代理的内部通信)
 func (boarder *Boarder) Handle(httpMethod, relativePath string, handlers ...HandlerFunc) IRoutes {
-	if matches, err := regexp.MatchString("^[A-Z]+$", httpMethod); !matches || err != nil {
-		panic("http method " + httpMethod + " is not valid")
-	}
+	assertMethod(httpMethod)
 	return boarder.handle(httpMethod, relativePath, handlers)
 }
 
+// HandleWithOptions 同Handle(),但可以附加路由注册选项.
+// router.HandleWithOptions("GET", "/files/*filepath", []web.RouteOption{web.WithUnescape(false)}, handler)
+func (boarder *Boarder) HandleWithOptions(httpMethod, relativePath string, opts []RouteOption, handlers ...HandlerFunc) IRoutes {
+	assertMethod(httpMethod)
+	return boarder.handle(httpMethod, relativePath, handlers, opts...)
+}
+
 // POST router.Handle("POST", path, handle)的语法糖.
 func (boarder *Boarder) POST(relativePath string, handlers ...HandlerFunc) IRoutes {
 	return boarder.handle(http.MethodPost, relativePath, handlers)
@@ -128,21 +162,45 @@ func (boarder *Boarder) Any(relativePath string, handlers ...HandlerFunc) IRoute
 	return boarder.returnObj()
 }
 
+// Match 为methods中的每个HTTP方法注册相同的路由,方法的校验同Handle().
+// 先校验所有方法再注册,有无效方法时panic且不注册任何路由.
+// router.Match([]string{"GET", "POST"}, "/login", handler)
+func (boarder *Boarder) Match(methods []string, relativePath string, handlers ...HandlerFunc) IRoutes {
+	for _, method := range methods {
+		assertMethod(method)
+	}
+	for _, method := range methods {
+		boarder.handle(method, relativePath, handlers)
+	}
+	return boarder.returnObj()
+}
+
 // StaticFile 静态文件路由注册(单).
 // router.StaticFile("favicon.ico", "./resources/favicon.ico")
 func (boarder *Boarder) StaticFile(relativePath, filepath string) IRoutes {
+	return boarder.staticFileHandler(relativePath, func(c *Context) {
+		c.File(filepath)
+	})
+}
+
+// StaticFileFS 同StaticFile(),但它从自定义的http.FileSystem读取文件(如embed).
+// router.StaticFileFS("favicon.ico", "./resources/favicon.ico", Dir(".", false))
+func (boarder *Boarder) StaticFileFS(relativePath, filepath string, fs http.FileSystem) IRoutes {
+	return boarder.staticFileHandler(relativePath, func(c *Context) {
+		c.FileFromFS(filepath, fs)
+	})
+}
+
+func (boarder *Boarder) staticFileHandler(relativePath string, handler HandlerFunc) IRoutes {
 	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
 		panic("URL parameters can not be used when serving a static file")
 	}
-	handler := func(c *Context) {
-		c.File(filepath)
-	}
 	boarder.GET(relativePath, handler)
 	boarder.HEAD(relativePath, handler)
 	return boarder.returnObj()
 }
 
-// Static 静态文件夹路由注册.
+// Static 静态文件夹路由注册(禁用目录列表,请求目录时提供其中的index.html,没有时返回404).
 // 内部的 http.FileServer 被使用,因此 http.NotFound 不是用来替代路由的 NotFound handler.
 func (boarder *Boarder) Static(relativePath, root string) IRoutes {
 	return boarder.StaticFS(relativePath, Dir(root, false))
@@ -162,12 +220,46 @@ func (boarder *Boarder) StaticFS(relativePath string, fs http.FileSystem) IRoute
 	return boarder.returnObj()
 }
 
+// StaticFSAliases 同StaticFS(),但将同一个http.FileSystem注册到多个路径前缀下.
+// router.StaticFSAliases([]string{"/static", "/v2/static"}, http.Dir("assets"))
+func (boarder *Boarder) StaticFSAliases(prefixes []string, fs http.FileSystem) IRoutes {
+	for _, prefix := range prefixes {
+		boarder.StaticFS(prefix, fs)
+	}
+	return boarder.returnObj()
+}
+
+// Mount 将h(如另一个*Centre)挂载到relativePath下:为所有HTTP方法注册relativePath+"/*mountpath",
+// 去掉前缀后交给h处理(如/admin/users -> /users),c.Request保持原始路径.
+// 前缀下已有路由时panic并指出冲突的路由;Mount之后前缀下也不能再注册其他路由.
+// router.Mount("/admin", adminCentre)
+func (boarder *Boarder) Mount(relativePath string, h http.Handler) IRoutes {
+	if strings.Contains(relativePath, ":") || strings.Contains(relativePath, "*") {
+		panic("URL parameters can not be used when mounting a handler")
+	}
+	prefix := strings.TrimSuffix(boarder.calculateAbsolutePath(relativePath), "/")
+	mountPath := prefix + "/*mountpath"
+	trees := boarder.centre.trees
+	if boarder.host != "" {
+		trees = *boarder.centre.hostTrees(boarder.host)
+	}
+	for _, tree := range trees {
+		for _, route := range iterate("", tree.method, nil, tree.root) {
+			if route.Path == prefix || strings.HasPrefix(route.Path, prefix+"/") {
+				panic(fmt.Sprintf("Mount '%s' 与已注册的路由 '%s %s' 冲突", mountPath, route.Method, route.Path))
+			}
+		}
+	}
+	return boarder.Any(path.Join(relativePath, "/*mountpath"), WrapH(http.StripPrefix(prefix, h)))
+}
+
 func (boarder *Boarder) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
 	absolutePath := boarder.calculateAbsolutePath(relativePath)
 	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
 
 	return func(c *Context) {
-		if _, nolisting := fs.(*onlyfilesFS); nolisting {
+		_, nolisting := fs.(*onlyfilesFS)
+		if nolisting {
 			c.Writer.WriteHeader(http.StatusNotFound)
 		}
 
@@ -175,18 +267,68 @@ func (boarder *Boarder) createStaticHandler(relativePath string, fs http.FileSys
 		// Check if file exists and/or if we have permission to access it
 		f, err := fs.Open(file)
 		if err != nil {
-			c.Writer.WriteHeader(http.StatusNotFound)
-			c.handlers = boarder.centre.noRoute
-			// Reset index
-			c.index = -1
+			boarder.serveStaticNotFound(c)
 			return
 		}
+		stat, err := f.Stat()
 		f.Close()
 
+		// 禁用目录列表时,目录由http.FileServer提供其中的index.html,没有index.html时按404处理
+		if err == nil && stat.IsDir() && nolisting {
+			index, err := fs.Open(path.Join(file, "index.html"))
+			if err != nil {
+				boarder.serveStaticNotFound(c)
+				return
+			}
+			index.Close()
+		}
+
+		if err == nil && !stat.IsDir() && serveGzipped(c, fs, file) {
+			return
+		}
 		fileServer.ServeHTTP(c.Writer, c.Request)
 	}
 }
 
+// serveStaticNotFound 静态文件不存在时交给NoRoute处理程序.
+func (boarder *Boarder) serveStaticNotFound(c *Context) {
+	c.Writer.WriteHeader(http.StatusNotFound)
+	c.handlers = boarder.centre.noRoute
+	// Reset index
+	c.index = -1
+}
+
+// serveGzipped 客户端接受gzip且存在预压缩的file+".gz"时,以Content-Encoding: gzip和原文件的Content-Type写入它.
+// 只要存在.gz文件就设置Vary: Accept-Encoding,使缓存区分压缩与未压缩的响应.
+// 未写入时返回false,由调用者继续提供未压缩的文件.
+func serveGzipped(c *Context, fs http.FileSystem, file string) bool {
+	gz, err := fs.Open(file + ".gz")
+	if err != nil {
+		return false
+	}
+	defer gz.Close()
+	stat, err := gz.Stat()
+	if err != nil || stat.IsDir() {
+		return false
+	}
+	header := c.Writer.Header()
+	header.Add("Vary", "Accept-Encoding")
+	accepted := parseAccept(c.requestHeader("Accept-Encoding"))
+	if !containsString(accepted, "gzip") && !containsString(accepted, "*") {
+		return false
+	}
+
+	// 必须设置Content-Type,否则ServeContent会嗅探出压缩数据的类型
+	ctype := mime.TypeByExtension(path.Ext(file))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	header.Set("Content-Type", ctype)
+	header.Set("Content-Encoding", "gzip")
+	http.ServeContent(c.Writer, c.Request, file, stat.ModTime(), gz)
+	return true
+}
+
 func (boarder *Boarder) combineHandlers(handlers HandlersChain) HandlersChain {
 	finalSize := len(boarder.Handlers) + len(handlers)
 	if finalSize >= int(abortIndex) {
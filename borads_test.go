@@ -0,0 +1,250 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestBoarderMount(t *testing.T) {
+	sub := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s %s", req.Method, req.URL.Path)
+	})
+	r := New()
+	var original string
+	r.Use(func(c *Context) {
+		c.Next()
+		original = c.Request.URL.Path
+	})
+	r.GET("/status", func(c *Context) { c.String(http.StatusOK, "status") })
+	r.Board("/v1").Mount("/admin", sub)
+
+	tests := []struct{ method, path, body string }{
+		{http.MethodGet, "/v1/admin/users", "GET /users"},
+		{http.MethodPost, "/v1/admin/users/1", "POST /users/1"},
+		{http.MethodGet, "/v1/admin/", "GET /"},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, tt.method, tt.path)
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("%s %s = %d %q, want %q", tt.method, tt.path, w.Code, w.Body.String(), tt.body)
+		}
+		if original != tt.path {
+			t.Errorf("%s %s: c.Request.URL.Path after delegation = %q", tt.method, tt.path, original)
+		}
+	}
+	if w := performRequest(r, http.MethodGet, "/status"); w.Body.String() != "status" {
+		t.Errorf("GET /status = %q", w.Body.String())
+	}
+}
+
+func TestBoarderMountConflict(t *testing.T) {
+	r := New()
+	r.POST("/admin/login", func(c *Context) {})
+
+	defer func() {
+		rec := recover()
+		msg, _ := rec.(string)
+		if !strings.Contains(msg, "/admin/*mountpath") || !strings.Contains(msg, "POST /admin/login") {
+			t.Errorf("Mount panic = %v, want both routes named", rec)
+		}
+	}()
+	r.Mount("/admin", http.NotFoundHandler())
+}
+
+func TestBoarderMatch(t *testing.T) {
+	r := New()
+	r.HandleMethodNotAllowed = true
+	r.Match([]string{http.MethodGet, http.MethodPost}, "/login", func(c *Context) {
+		c.String(http.StatusOK, c.Request.Method)
+	})
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		if w := performRequest(r, method, "/login"); w.Code != http.StatusOK || w.Body.String() != method {
+			t.Errorf("%s /login = %d %q", method, w.Code, w.Body.String())
+		}
+	}
+	if w := performRequest(r, http.MethodPut, "/login"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /login status = %d, want 405", w.Code)
+	}
+	r.HandleMethodNotAllowed = false
+	if w := performRequest(r, http.MethodPut, "/login"); w.Code != http.StatusNotFound {
+		t.Errorf("PUT /login status = %d, want 404", w.Code)
+	}
+}
+
+func TestBoarderMatchValidatesBeforeRegistering(t *testing.T) {
+	r := New()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Match with an invalid method did not panic")
+			}
+		}()
+		r.Match([]string{http.MethodGet, "po st"}, "/login", func(c *Context) {})
+	}()
+	if routes := r.Routes(); len(routes) != 0 {
+		t.Errorf("routes registered before validation failed: %v", routes)
+	}
+}
+
+//go:embed testdata/favicon.ico
+var testdataFS embed.FS
+
+func TestBoarderStaticFileFS(t *testing.T) {
+	r := New()
+	r.StaticFileFS("/favicon.ico", "testdata/favicon.ico", http.FS(testdataFS))
+
+	want, _ := testdataFS.ReadFile("testdata/favicon.ico")
+	w := performRequest(r, http.MethodGet, "/favicon.ico")
+	if w.Code != http.StatusOK || w.Body.String() != string(want) {
+		t.Errorf("GET /favicon.ico = %d %q", w.Code, w.Body.String())
+	}
+	head := performRequest(r, http.MethodHead, "/favicon.ico")
+	if head.Code != http.StatusOK || head.Body.Len() != 0 {
+		t.Errorf("HEAD /favicon.ico = %d, body %q", head.Code, head.Body.String())
+	}
+	if got := head.Header().Get("Content-Length"); got != fmt.Sprint(len(want)) {
+		t.Errorf("HEAD Content-Length = %q, want %d", got, len(want))
+	}
+
+	for _, path := range []string{"/:name", "/*file"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("StaticFileFS(%q) did not panic", path)
+				}
+			}()
+			r.StaticFileFS(path, "testdata/favicon.ico", http.FS(testdataFS))
+		}()
+	}
+}
+
+func TestBoarderStaticFSAliases(t *testing.T) {
+	r := New()
+	r.StaticFSAliases([]string{"/static", "/v2/static"}, http.FS(testdataFS))
+
+	want, _ := testdataFS.ReadFile("testdata/favicon.ico")
+	for _, prefix := range []string{"/static", "/v2/static"} {
+		w := performRequest(r, http.MethodGet, prefix+"/testdata/favicon.ico")
+		if w.Code != http.StatusOK || w.Body.String() != string(want) {
+			t.Errorf("GET %s/testdata/favicon.ico = %d %q", prefix, w.Code, w.Body.String())
+		}
+		if w := performRequest(r, http.MethodGet, prefix+"/testdata/missing.ico"); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s/testdata/missing.ico = %d, want 404", prefix, w.Code)
+		}
+	}
+}
+
+func TestBoarderStaticPrecompressed(t *testing.T) {
+	const js = "console.log('hello');"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(js)) // nolint: errcheck
+	zw.Close()
+	r := New()
+	r.StaticFS("/assets", http.FS(fstest.MapFS{
+		"app.js":    {Data: []byte(js)},
+		"app.js.gz": {Data: gz.Bytes()},
+		"plain.css": {Data: []byte("body{}")},
+	}))
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/assets/app.js", "br;q=1.0, gzip;q=0.8")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip: status %d, Content-Encoding %q", w.Code, w.Header().Get("Content-Encoding"))
+	}
+	if ctype := w.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "text/javascript") {
+		t.Errorf("gzip: Content-Type = %q, want text/javascript", ctype)
+	}
+	if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("gzip: Vary = %q", vary)
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != js {
+		t.Errorf("gzip: decompressed body = %q, want %q", body, js)
+	}
+
+	w = get("/assets/app.js", "")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != js {
+		t.Errorf("identity: Content-Encoding %q, body %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if vary := w.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Errorf("identity with .gz sibling: Vary = %q", vary)
+	}
+
+	w = get("/assets/plain.css", "gzip")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "body{}" {
+		t.Errorf("no .gz sibling: Content-Encoding %q, body %q", w.Header().Get("Content-Encoding"), w.Body.String())
+	}
+	if vary := w.Header().Get("Vary"); vary != "" {
+		t.Errorf("no .gz sibling: Vary = %q", vary)
+	}
+}
+
+func TestBoarderStaticDirectoryIndex(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"index.html":      "home",
+		"docs/index.html": "docs home",
+		"docs/guide.html": "guide",
+		"empty/a.txt":     "a",
+	}
+	for name, content := range files {
+		full := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := New()
+	r.Static("/site", root)
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"/site/", http.StatusOK, "home"},
+		{"/site/docs/", http.StatusOK, "docs home"},
+		{"/site/docs/guide.html", http.StatusOK, "guide"},
+		{"/site/empty/", http.StatusNotFound, ""},
+		{"/site/empty/a.txt", http.StatusOK, "a"},
+		{"/site/missing/", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, http.MethodGet, tt.path)
+		if w.Code != tt.wantCode {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.wantCode)
+		}
+		if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+			t.Errorf("GET %s body = %q, want %q", tt.path, w.Body.String(), tt.wantBody)
+		}
+		if tt.wantCode == http.StatusNotFound && strings.Contains(w.Body.String(), "a.txt") {
+			t.Errorf("GET %s listed the directory: %q", tt.path, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder Compress使用的内容编码,Name为Content-Encoding的值(如"gzip","br"),New返回写入w的压缩写入器.
+// 压缩写入器实现Flush() error时,流式响应刷新时会先刷新它.
+type Encoder struct {
+	Name string
+	New  func(w io.Writer) io.WriteCloser
+}
+
+// CompressConfig Compress中间件的配置.
+type CompressConfig struct {
+	// Encoders 按服务端偏好排列的可用编码,客户端q值相同时选择靠前的.默认只有GzipEncoder(gzip.DefaultCompression).
+	// 标准库没有Brotli实现,需要br时可以注册第三方库构造的Encoder{Name: "br", New: ...}.
+	Encoders []Encoder
+	// MinSize 小于该字节数的响应不压缩(直接原样写入),0表示全部压缩.
+	MinSize int
+}
+
+// GzipEncoder 返回使用给定压缩级别的gzip编码,压缩写入器通过sync.Pool复用.
+func GzipEncoder(level int) Encoder {
+	if _, err := gzip.NewWriterLevel(io.Discard, level); err != nil {
+		panic(err)
+	}
+	pool := &sync.Pool{New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, level)
+		return gz
+	}}
+	return Encoder{
+		Name: "gzip",
+		New: func(w io.Writer) io.WriteCloser {
+			gz := pool.Get().(*gzip.Writer)
+			gz.Reset(w)
+			return &pooledGzipWriter{Writer: gz, pool: pool}
+		},
+	}
+}
+
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+// Close 结束gzip流并将写入器放回池中.
+func (w *pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	w.pool.Put(w.Writer)
+	return err
+}
+
+// Compress 返回按Accept-Encoding(含q值)协商压缩响应的中间件,设置Content-Encoding和Vary: Accept-Encoding.
+// 内置的编码只有gzip(GzipEncoder);标准库没有Brotli实现,br需要通过config.Encoders注册第三方库构造的Encoder.
+// 响应体先缓冲到MinSize再决定是否压缩;HEAD请求、websocket握手、已设置Content-Encoding或不允许响应体的状态码不压缩.
+func Compress(config CompressConfig) HandlerFunc {
+	encoders := config.Encoders
+	if len(encoders) == 0 {
+		encoders = []Encoder{GzipEncoder(gzip.DefaultCompression)}
+	}
+	return func(c *Context) {
+		if c.Request.Method == http.MethodHead || c.IsWebsocket() {
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoder := negotiateEncoding(c.requestHeader("Accept-Encoding"), encoders)
+		if encoder == nil {
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoder: encoder, minSize: config.MinSize}
+		c.Writer = w
+		defer func() {
+			w.finish()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding 返回客户端可接受且q值最高的编码,q值相同时按encoders顺序,都不可接受时返回nil.
+func negotiateEncoding(acceptEncoding string, encoders []Encoder) *Encoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseQuality(part)
+		if name != "" {
+			qualities[strings.ToLower(name)] = q
+		}
+	}
+
+	var best *Encoder
+	bestQ := 0.0
+	for i := range encoders {
+		q, ok := qualities[encoders[i].Name]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = &encoders[i], q
+		}
+	}
+	return best
+}
+
+// parseQuality 解析"gzip;q=0.8"形式的条目,没有q参数时q为1,无效的q视为0.
+func parseQuality(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	name := strings.TrimSpace(params[0])
+	q := 1.0
+	for _, param := range params[1:] {
+		param = strings.TrimSpace(param)
+		if len(param) > 2 && (param[0] == 'q' || param[0] == 'Q') && param[1] == '=' {
+			v, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil {
+				v = 0
+			}
+			q = v
+		}
+	}
+	return name, q
+}
+
+// compressWriter 缓冲响应体直到达到minSize,之后经encoder压缩写入;结束时未达到minSize的响应原样写入.
+type compressWriter struct {
+	ResponseWriter
+	encoder   *Encoder
+	minSize   int
+	buf       bytes.Buffer
+	enc       io.WriteCloser
+	decided   bool
+	headerNow bool
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buf.Write(data)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 在决定是否压缩前推迟写入headers.
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+func (w *compressWriter) Written() bool {
+	return w.headerNow || w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Size 返回已写入底层的(压缩后的)字节数加上尚在缓冲中的字节数.
+func (w *compressWriter) Size() int {
+	size := w.ResponseWriter.Size()
+	if w.buf.Len() > 0 {
+		if size < 0 {
+			size = 0
+		}
+		size += w.buf.Len()
+	}
+	return size
+}
+
+// Flush 实现http.Flusher接口.刷新意味着流式响应,不再等待minSize,立即开始压缩.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide() // nolint: errcheck
+	}
+	if flusher, ok := w.enc.(interface{ Flush() error }); ok {
+		flusher.Flush() // nolint: errcheck
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 按当前状态码和headers决定是否压缩,并写出已缓冲的数据.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if header.Get("Content-Encoding") == "" && bodyAllowedForStatus(w.Status()) {
+		header.Set("Content-Encoding", w.encoder.Name)
+		header.Del("Content-Length")
+		w.enc = w.encoder.New(w.ResponseWriter)
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+// finish 结束压缩流;未达到minSize的响应原样写入.
+func (w *compressWriter) finish() {
+	if w.enc != nil {
+		w.enc.Close() // nolint: errcheck
+		return
+	}
+	if w.decided {
+		return
+	}
+	if w.buf.Len() > 0 {
+		w.ResponseWriter.Write(w.buf.Bytes()) // nolint: errcheck
+	} else if w.headerNow {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testBrEncoder 测试用的"br"编码,原样写入并加上前缀,用来确认选择了br.
+var testBrEncoder = Encoder{Name: "br", New: func(w io.Writer) io.WriteCloser {
+	io.WriteString(w, "br:") // nolint: errcheck
+	return nopWriteCloser{w}
+}}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func compressRequest(r http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	body := strings.Repeat("hello compress ", 20)
+	r := New()
+	r.Use(Compress(CompressConfig{Encoders: []Encoder{GzipEncoder(gzip.DefaultCompression), testBrEncoder}}))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, body) })
+
+	w := compressRequest(r, "gzip;q=0.5, br;q=0.9")
+	if got := w.Header().Get("Content-Encoding"); got != "br" || w.Body.String() != "br:"+body {
+		t.Errorf("prefer br: Content-Encoding = %q, body = %q", got, w.Body.String())
+	}
+
+	w = compressRequest(r, "br;q=0.2, gzip")
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("prefer gzip: Content-Encoding = %q", got)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, _ := io.ReadAll(gz); string(plain) != body {
+		t.Errorf("prefer gzip: decompressed body = %q", plain)
+	}
+
+	for _, accept := range []string{"", "identity", "gzip;q=0, br;q=0"} {
+		w = compressRequest(r, accept)
+		if got := w.Header().Get("Content-Encoding"); got != "" || w.Body.String() != body {
+			t.Errorf("Accept-Encoding %q: Content-Encoding = %q, body = %q", accept, got, w.Body.String())
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q: Vary = %q", accept, got)
+		}
+	}
+}
+
+func TestCompressMinSize(t *testing.T) {
+	r := New()
+	r.Use(Compress(CompressConfig{MinSize: 64}))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, c.DefaultQuery("body", "tiny")) })
+
+	w := compressRequest(r, "gzip")
+	if got := w.Header().Get("Content-Encoding"); got != "" || w.Body.String() != "tiny" {
+		t.Errorf("small response: Content-Encoding = %q, body = %q", got, w.Body.String())
+	}
+}
+
+func TestCompressReportsCompressedSize(t *testing.T) {
+	body := strings.Repeat("a", 4096)
+	var outer, inner int
+	var innerWritten bool
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		outer = c.Writer.Size()
+	})
+	r.Use(Compress(CompressConfig{MinSize: 8192}))
+	r.GET("/", func(c *Context) {
+		c.String(http.StatusOK, body)
+		inner, innerWritten = c.Writer.Size(), c.Writer.Written()
+	})
+
+	w := compressRequest(r, "gzip")
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response below MinSize was compressed")
+	}
+	if !innerWritten || inner != len(body) {
+		t.Errorf("buffered Size = %d, Written = %v, want %d, true", inner, innerWritten, len(body))
+	}
+
+	r = New()
+	r.Use(func(c *Context) {
+		c.Next()
+		outer = c.Writer.Size()
+	})
+	r.Use(Compress(CompressConfig{}))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, body) })
+	w = compressRequest(r, "gzip")
+	if outer != w.Body.Len() || bytes.Equal(w.Body.Bytes(), []byte(body)) {
+		t.Errorf("logged Size = %d, compressed body length = %d", outer, w.Body.Len())
+	}
+}
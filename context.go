@@ -1,6 +1,7 @@
 package web
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -30,6 +32,7 @@ const (
 	MIMEXML               = binding.MIMEXML
 	MIMEXML2              = binding.MIMEXML2
 	BodyBytesKey          = "_lierbai/web/bodybyteskey"
+	AbortReasonKey        = "_lierbai/web/abortreasonkey"
 )
 const abortIndex int8 = math.MaxInt8 / 2
 
@@ -45,11 +48,14 @@ type Context struct {
 	centre     *Centre                // web中枢结构体指针
 	mu         sync.RWMutex           // 互斥私有键map
 	Keys       map[string]interface{} // 专门用于上下文的键/值对
+	expires    map[string]time.Time   // SetWithTTL设置的键的过期时间
 	Errors     errorMsgs              // Errors 错误列表.
 	Accepted   []string               // 定义了允许的格式被用于内容协商(content)
 	queryCache url.Values             // 缓存参数查询结果(c.Request.URL.Query())
 	formCache  url.Values             // 缓存PostForm包含的表单数据(来自POST,PATCH,PUT)
 	sameSite   http.SameSite          // Cookie 限制
+	maxMemory  int64                  // 解析multipart form时使用的最大内存,默认centre.MaxMultipartMemory
+	namedOut   *string                // 非nil时表示由nameOfFunction探测Named名称,不会出现在请求处理中
 }
 
 func (c *Context) reset() {
@@ -59,10 +65,13 @@ func (c *Context) reset() {
 	c.index = -1
 	c.fullPath = ""
 	c.Keys = nil
+	c.expires = nil
 	c.Errors = c.Errors[0:0]
 	c.Accepted = nil
 	c.queryCache = nil
 	c.formCache = nil
+	c.sameSite = c.centre.DefaultSameSite
+	c.maxMemory = c.centre.MaxMultipartMemory
 }
 
 // Copy 复制可在请求范围外安全使用的副本.必须将context传递给goroutine时必须使用该方法.
@@ -74,6 +83,7 @@ func (c *Context) Copy() *Context {
 		centre:    c.centre,
 	}
 	cp.writermem.ResponseWriter = nil
+	cp.writermem.onError = nil
 	cp.Writer = &cp.writermem
 	cp.index = abortIndex
 	cp.handlers = nil
@@ -81,6 +91,12 @@ func (c *Context) Copy() *Context {
 	for k, v := range c.Keys {
 		cp.Keys[k] = v
 	}
+	if c.expires != nil {
+		cp.expires = make(map[string]time.Time, len(c.expires))
+		for k, t := range c.expires {
+			cp.expires[k] = t
+		}
+	}
 	paramCopy := make([]Param, len(cp.Params))
 	copy(paramCopy, cp.Params)
 	cp.Params = paramCopy
@@ -135,6 +151,15 @@ func (c *Context) Abort() {
 	c.index = abortIndex
 }
 
+// AbortToHandler 中止当前的链,并在同一请求中立即执行h(如自定义的错误页|登录页处理程序).
+// h执行后链保持中止状态,调用本方法的handler中后续的代码仍会执行.
+func (c *Context) AbortToHandler(h HandlerFunc) {
+	c.handlers = HandlersChain{h}
+	c.index = -1
+	c.Next()
+	c.Abort()
+}
+
 // AbortWithStatus 调用Abort()方法,并写入响应体状态码.
 // 例如,身份验证失败返回401
 func (c *Context) AbortWithStatus(code int) {
@@ -143,6 +168,12 @@ func (c *Context) AbortWithStatus(code int) {
 	c.Abort()
 }
 
+// AbortWithReason 调用AbortWithStatus()方法,并将中止原因存储在AbortReasonKey下(可被日志记录).
+func (c *Context) AbortWithReason(code int, reason string) {
+	c.Set(AbortReasonKey, reason)
+	c.AbortWithStatus(code)
+}
+
 // AbortWithStatusJSON 调用Abort()方法,并调用JSON方法.
 func (c *Context) AbortWithStatusJSON(code int, jsonObj interface{}) {
 	c.Abort()
@@ -187,13 +218,32 @@ func (c *Context) Set(key string, value interface{}) {
 	}
 
 	c.Keys[key] = value
+	delete(c.expires, key)
 	c.mu.Unlock()
 }
 
-// Get 返回给定的值,和true.空返回(nil, false)
+// SetWithTTL 与Set相同,但键值对在ttl后过期,过期后Get视其为不存在.适用于长时间运行的流式请求中的缓存.
+func (c *Context) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	if c.expires == nil {
+		c.expires = make(map[string]time.Time)
+	}
+
+	c.Keys[key] = value
+	c.expires[key] = time.Now().Add(ttl)
+	c.mu.Unlock()
+}
+
+// Get 返回给定的值,和true.空或已过期返回(nil, false)
 func (c *Context) Get(key string) (value interface{}, exists bool) {
 	c.mu.RLock()
 	value, exists = c.Keys[key]
+	if exp, ok := c.expires[key]; ok && exists && !time.Now().Before(exp) {
+		value, exists = nil, false
+	}
 	c.mu.RUnlock()
 	return
 }
@@ -206,6 +256,18 @@ func (c *Context) MustGet(key string) interface{} {
 	panic("Key \"" + key + "\" does not exist")
 }
 
+// Get 返回上下文中key对应的T类型的值,不存在或类型不是T时返回T的零值和false.
+// user, ok := web.Get[*User](c, "user")
+func Get[T any](c *Context, key string) (T, bool) {
+	val, ok := c.Get(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	t, ok := val.(T)
+	return t, ok
+}
+
 // GetString 返回字符串类型的给定值.
 func (c *Context) GetString(key string) (s string) {
 	if val, ok := c.Get(key); ok && val != nil {
@@ -301,6 +363,31 @@ func (c *Context) Param(key string) string {
 	return c.Params.ByName(key)
 }
 
+// ParamsMap 以map返回所有URL参数(如用于日志|链路追踪),通配参数的值包含开头的'/'.
+func (c *Context) ParamsMap() map[string]string {
+	return c.Params.Map()
+}
+
+// ParamInt 返回URL里指定键的int值.键不存在或无法转换时返回(0, false).
+func (c *Context) ParamInt(key string) (int, bool) {
+	if value, ok := c.Params.Get(key); ok {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ParamInt64 返回URL里指定键的int64值.键不存在或无法转换时返回(0, false).
+func (c *Context) ParamInt64(key string) (int64, bool) {
+	if value, ok := c.Params.Get(key); ok {
+		if i64, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i64, true
+		}
+	}
+	return 0, false
+}
+
 // Query 返回URL中的值."/path?id=1&name=Manu",c.Query("id")=="1234"
 // 相当于 `c.Request.URL.Query().Get(key)`
 func (c *Context) Query(key string) string {
@@ -330,6 +417,17 @@ func (c *Context) GetQuery(key string) (string, bool) {
 	return "", false
 }
 
+// QueryFirst 按顺序查找keys,返回第一个存在的查询参数的值及其键名.都不存在返回("", "", false).
+//     c.QueryFirst("q", "query", "search")
+func (c *Context) QueryFirst(keys ...string) (value string, key string, ok bool) {
+	for _, key = range keys {
+		if value, ok = c.GetQuery(key); ok {
+			return value, key, true
+		}
+	}
+	return "", "", false
+}
+
 // QueryArray 返回指定键的[]string.长度取决于包含指定键的参数数量.
 func (c *Context) QueryArray(key string) []string {
 	values, _ := c.GetQueryArray(key)
@@ -386,6 +484,24 @@ func (c *Context) GetPostForm(key string) (string, bool) {
 	return "", false
 }
 
+// PostFormIntE 返回表单指定键的int值.键不存在或无法转换时返回错误.
+func (c *Context) PostFormIntE(key string) (int, error) {
+	value, ok := c.GetPostForm(key)
+	if !ok {
+		return 0, fmt.Errorf("表单中不存在键'%s'", key)
+	}
+	return strconv.Atoi(value)
+}
+
+// PostFormBoolE 返回表单指定键的bool值.键不存在或无法转换时返回错误.
+func (c *Context) PostFormBoolE(key string) (bool, error) {
+	value, ok := c.GetPostForm(key)
+	if !ok {
+		return false, fmt.Errorf("表单中不存在键'%s'", key)
+	}
+	return strconv.ParseBool(value)
+}
+
 // PostFormArray 根据表单key返回字符串数组.
 func (c *Context) PostFormArray(key string) []string {
 	values, _ := c.GetPostFormArray(key)
@@ -396,7 +512,7 @@ func (c *Context) getFormCache() {
 	if c.formCache == nil {
 		c.formCache = make(url.Values)
 		req := c.Request
-		if err := req.ParseMultipartForm(c.centre.MaxMultipartMemory); err != nil {
+		if err := req.ParseMultipartForm(c.maxMemory); err != nil {
 			if err != http.ErrNotMultipart {
 				debugPrint("error on parse multipart form array: %v", err)
 			}
@@ -444,7 +560,7 @@ func (c *Context) get(m map[string][]string, key string) (map[string]string, boo
 // FormFile 按键返回第一个文件.
 func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
 	if c.Request.MultipartForm == nil {
-		if err := c.Request.ParseMultipartForm(c.centre.MaxMultipartMemory); err != nil {
+		if err := c.Request.ParseMultipartForm(c.maxMemory); err != nil {
 			return nil, err
 		}
 	}
@@ -456,9 +572,27 @@ func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
 	return fh, err
 }
 
+// FormFiles 按键返回所有文件(如<input type="file" multiple>).不存在返回http.ErrMissingFile.
+func (c *Context) FormFiles(name string) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	if files := form.File[name]; len(files) > 0 {
+		return files, nil
+	}
+	return nil, http.ErrMissingFile
+}
+
+// SetMaxMultipartMemory 为当前请求覆盖centre.MaxMultipartMemory,超出的文件部分会写入临时文件.
+// 必须在首次解析表单(PostForm|FormFile|MultipartForm等)之前调用.
+func (c *Context) SetMaxMultipartMemory(n int64) {
+	c.maxMemory = n
+}
+
 // MultipartForm 返回解析的multipart form(包含文件上传).
 func (c *Context) MultipartForm() (*multipart.Form, error) {
-	err := c.Request.ParseMultipartForm(c.centre.MaxMultipartMemory)
+	err := c.Request.ParseMultipartForm(c.maxMemory)
 	return c.Request.MultipartForm, err
 }
 
@@ -517,9 +651,24 @@ func (c *Context) BindUri(obj interface{}) error {
 }
 
 // MustBindWith 使用binding engine绑定传递的struct指针.错误返回http400.
+// 验证失败时,附加错误的Meta为 字段名->未通过的规则 的映射(map[string]string,见binding.FieldRules).
+// 设置了centre.BindErrorRenderer时,中止后由它渲染响应(错误仍会附加到c.Errors).
 func (c *Context) MustBindWith(obj interface{}, b binding.Binding) error {
 	if err := c.ShouldBindWith(obj, b); err != nil {
-		c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind) // nolint: errcheck
+		renderer := c.centre.BindErrorRenderer
+		var e *Error
+		if renderer != nil {
+			c.Abort()
+			e = c.Error(err).SetType(ErrorTypeBind)
+		} else {
+			e = c.AbortWithError(http.StatusBadRequest, err).SetType(ErrorTypeBind)
+		}
+		if rules := binding.FieldRules(err); rules != nil {
+			e.SetMeta(rules)
+		}
+		if renderer != nil {
+			renderer(c, err)
+		}
 		return err
 	}
 	return nil
@@ -531,17 +680,58 @@ func (c *Context) ShouldBind(obj interface{}) error {
 	return c.ShouldBindWith(obj, b)
 }
 
+// ShouldBindQueryForNonGet 与ShouldBind相同,但没有请求体的非GET请求(如DELETE ?id=1)使用查询参数绑定.
+// GET请求及带请求体的请求仍按Content-Type选择绑定.
+func (c *Context) ShouldBindQueryForNonGet(obj interface{}) error {
+	if c.Request.Method != http.MethodGet && c.Request.ContentLength == 0 {
+		return c.ShouldBindWith(obj, binding.Query)
+	}
+	return c.ShouldBind(obj)
+}
+
+// BindAndValidate 与ShouldBind相同,但不写入响应,验证失败时返回 字段名->错误描述 的映射和false,便于重新渲染表单.
+// 非验证错误(如请求体格式错误)以空字符串为键返回.
+func (c *Context) BindAndValidate(obj interface{}) (map[string]string, bool) {
+	err := c.ShouldBind(obj)
+	if err == nil {
+		return nil, true
+	}
+	if fields := binding.FieldErrors(err); fields != nil {
+		return fields, false
+	}
+	return map[string]string{"": err.Error()}, false
+}
+
 // ShouldBindJSON c.ShouldBindWith(obj, binding.JSON)的语法糖.
 func (c *Context) ShouldBindJSON(obj interface{}) error {
 	return c.ShouldBindWith(obj, binding.JSON)
 }
 
+// ShouldBindJSONSchema 先用JSON Schema校验请求体,再绑定到obj,违规时返回binding.SchemaErrors.
+func (c *Context) ShouldBindJSONSchema(obj interface{}, schema []byte) error {
+	return c.ShouldBindWith(obj, binding.JSONSchema(schema))
+}
+
+// MustBindJSON 同ShouldBindJSON,但绑定失败时panic(由Recovery捕获并返回500),适用于请求错误即程序错误的内部接口.
+// 与BindJSON不同,它不会写入400响应,panic的堆栈可以直接定位问题.
+func (c *Context) MustBindJSON(obj interface{}) {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		panic(err)
+	}
+}
+
+// ShouldBindJSONAndQuery 先绑定查询参数,再绑定JSON请求体到同一个obj(请求体优先),适用于分页参数在查询中而数据在请求体中的接口.
+func (c *Context) ShouldBindJSONAndQuery(obj interface{}) error {
+	return c.ShouldBindWith(obj, binding.JSONAndQuery)
+}
+
 // ShouldBindXML c.ShouldBindWith(obj, binding.XML)的语法糖.
 func (c *Context) ShouldBindXML(obj interface{}) error {
 	return c.ShouldBindWith(obj, binding.XML)
 }
 
 // ShouldBindQuery c.ShouldBindWith(obj, binding.Query)的语法糖.
+// 指针字段仅在参数存在时才会被分配,缺失时保持nil.因此`*int`配合`binding:"required"`可以区分缺失与零值.
 func (c *Context) ShouldBindQuery(obj interface{}) error {
 	return c.ShouldBindWith(obj, binding.Query)
 }
@@ -568,37 +758,39 @@ func (c *Context) ShouldBindWith(obj interface{}, b binding.Binding) error {
 // ShouldBindBodyWith 将请求体存储在context,并可以在再次调用时使用.
 // 值得注意的是,此函数在绑定前读取,如果只需读取一次,用它可以获得更好的性能体验.
 func (c *Context) ShouldBindBodyWith(obj interface{}, bb binding.BindingBody) (err error) {
-	var body []byte
-	if cb, ok := c.Get(BodyBytesKey); ok {
-		if cbb, ok := cb.([]byte); ok {
-			body = cbb
-		}
-	}
-	if body == nil {
-		body, err = ioutil.ReadAll(c.Request.Body)
-		if err != nil {
-			return err
-		}
-		c.Set(BodyBytesKey, body)
+	body, err := c.GetRawData()
+	if err != nil {
+		return err
 	}
 	return bb.BindBody(body, obj)
 }
 
-// ClientIP 实现一个尽力返回真实客户端IP的算法, 分析X-Real-IP 和 X-Forwarded-For以便正确处理反向代理,如: nginx|haproxy.
-// Use X-Forwarded-For before X-Real-Ip as nginx uses X-Real-Ip with the proxy's IP.
+// ClientIP 实现一个尽力返回真实客户端IP的算法, 按centre.RemoteIPHeaders的顺序分析headers以便正确处理反向代理,如: nginx|haproxy.
+// 默认顺序为Forwarded, X-Forwarded-For, X-Real-Ip(nginx的X-Real-Ip是代理的IP),多值header取第一个逗号分隔的值.
+// centre.TrustForwardedOnlyOnTLS为true时,明文连接忽略所有携带客户端IP的headers(TrustedPlatform,RemoteIPHeaders,X-Appengine-Remote-Addr).
 func (c *Context) ClientIP() string {
-	if c.centre.ForwardedByClientIP {
-		clientIP := c.requestHeader("X-Forwarded-For")
-		clientIP = strings.TrimSpace(strings.Split(clientIP, ",")[0])
-		if clientIP == "" {
-			clientIP = strings.TrimSpace(c.requestHeader("X-Real-Ip"))
+	trustHeaders := !c.centre.TrustForwardedOnlyOnTLS || c.Request.TLS != nil
+	if trustHeaders && c.centre.TrustedPlatform != "" {
+		if addr := c.requestHeader(c.centre.TrustedPlatform); addr != "" {
+			return addr
 		}
-		if clientIP != "" {
-			return clientIP
+	}
+
+	if trustHeaders && c.centre.ForwardedByClientIP {
+		for _, header := range c.centre.RemoteIPHeaders {
+			var clientIP string
+			if strings.EqualFold(header, "Forwarded") {
+				clientIP = parseForwardedFor(c.requestHeader(header))
+			} else {
+				clientIP = strings.TrimSpace(strings.Split(c.requestHeader(header), ",")[0])
+			}
+			if clientIP != "" {
+				return clientIP
+			}
 		}
 	}
 
-	if c.centre.AppCentre {
+	if trustHeaders && c.centre.AppCentre {
 		if addr := c.requestHeader("X-Appengine-Remote-Addr"); addr != "" {
 			return addr
 		}
@@ -611,6 +803,33 @@ func (c *Context) ClientIP() string {
 	return ""
 }
 
+// parseForwardedFor 返回RFC 7239 Forwarded header中第一跳的for=地址(去除引号、IPv6方括号和端口).
+// 地址为unknown或混淆标识(以_开头)时返回空字符串.
+func parseForwardedFor(header string) string {
+	first := strings.Split(header, ",")[0]
+	for _, pair := range strings.Split(first, ";") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) < 4 || !strings.EqualFold(pair[:4], "for=") {
+			continue
+		}
+		addr := strings.Trim(pair[4:], "\"")
+		if strings.HasPrefix(addr, "[") {
+			if end := strings.IndexByte(addr, ']'); end > 0 {
+				return addr[1:end]
+			}
+			return ""
+		}
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		if strings.EqualFold(addr, "unknown") || strings.HasPrefix(addr, "_") {
+			return ""
+		}
+		return addr
+	}
+	return ""
+}
+
 // ContentType 返回请求header的Content-Type.
 func (c *Context) ContentType() string {
 	return filterFlags(c.requestHeader("Content-Type"))
@@ -631,6 +850,12 @@ func (c *Context) requestHeader(key string) string {
 
 /**    响应体渲染    **/
 
+// WrapWriter 用fn封装当前的c.Writer,便于中间件(压缩|缓存|统计)组合观察响应.
+// 多次调用按顺序嵌套,每个请求开始时c.Writer都会被重置为基础写入器.
+func (c *Context) WrapWriter(fn func(ResponseWriter) ResponseWriter) {
+	c.Writer = fn(c.Writer)
+}
+
 // bodyAllowedForStatus 是http.bodyAllowedForStatus的非输出函数.
 func bodyAllowedForStatus(status int) bool {
 	switch {
@@ -644,11 +869,17 @@ func bodyAllowedForStatus(status int) bool {
 	return true
 }
 
-// Status 设置HTTP响应代码.
+// Status 设置HTTP响应代码.headers已写入后调用无效.
 func (c *Context) Status(code int) {
 	c.Writer.WriteHeader(code)
 }
 
+// Written 是c.Writer.Written()的语法糖,返回响应(状态码和headers)是否已写入,用于在渲染前判断是否安全.
+// 注意c.Status只记录状态码,在写入响应体或调用c.Writer.WriteHeaderNow()之前Written仍为false.
+func (c *Context) Written() bool {
+	return c.Writer.Written()
+}
+
 // Header 是c.Writer.Header().Set(key, value)的语法糖.写入header.
 // 如果值为空,将删除该header,即`c.Writer.Header().Del(key)`
 func (c *Context) Header(key, value string) {
@@ -665,11 +896,35 @@ func (c *Context) GetHeader(key string) string {
 }
 
 // GetRawData 返回流数据.
+// 读取结果缓存在BodyBytesKey下,并重置c.Request.Body,因此之后仍可再次读取或绑定请求体.
 func (c *Context) GetRawData() ([]byte, error) {
-	return ioutil.ReadAll(c.Request.Body)
+	if cb, ok := c.Get(BodyBytesKey); ok {
+		if cbb, ok := cb.([]byte); ok {
+			return cbb, nil
+		}
+	}
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.Set(BodyBytesKey, body)
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
 }
 
-// SetSameSite 同 cookie
+// TeeBody 封装c.Request.Body,每次读取请求体时都会把读到的数据同时写入w.
+// 便于日志记录与绑定共存,而无需完整缓存请求体.
+func (c *Context) TeeBody(w io.Writer) {
+	body := c.Request.Body
+	c.Request.Body = teeReadCloser{Reader: io.TeeReader(body, w), Closer: body}
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// SetSameSite 同 cookie,覆盖centre.DefaultSameSite(仅当前请求)
 func (c *Context) SetSameSite(samesite http.SameSite) {
 	c.sameSite = samesite
 }
@@ -692,6 +947,23 @@ func (c *Context) SetCookie(name, value string, maxAge int, path, domain string,
 	})
 }
 
+// ClearCookie 添加删除名为name的cookie的Set-Cookie header(Max-Age=0且Expires为过去的时间).
+// path和domain必须与设置时一致,浏览器才会删除它;path为空时使用"/".
+func (c *Context) ClearCookie(name, path, domain string) {
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    "",
+		MaxAge:   -1,
+		Expires:  time.Unix(0, 0),
+		Path:     path,
+		Domain:   domain,
+		SameSite: c.sameSite,
+	})
+}
+
 // Cookie 返回请求中名为name的cookies(未转义的).
 // 如果有多个同名 cookies 则只返回一个.
 func (c *Context) Cookie(name string) (string, error) {
@@ -728,23 +1000,56 @@ func (c *Context) HTML(code int, name string, obj interface{}) {
 // (随手设置了Content-Type).
 // 警告: 该方法虽然可读性高,但会比JSON()消耗更多资源,所以建议只在开发中使用.
 func (c *Context) IndentedJSON(code int, obj interface{}) {
-	c.Render(code, render.JSON{Indented: true, Data: obj})
+	c.Render(code, render.JSON{Indented: true, ContentLength: c.centre.JSONContentLength, Data: obj})
+}
+
+// IndentedJSONWith 与IndentedJSON相同,但使用indent作为每级的缩进(如"\t"或两个空格).
+func (c *Context) IndentedJSONWith(code int, indent string, obj interface{}) {
+	c.Render(code, render.JSON{Indented: true, Indent: indent, ContentLength: c.centre.JSONContentLength, Data: obj})
 }
 
 // JSON 将给定的结构序列化为JSON并写入(随手设置了Content-Type).
 // 在开发模式中,JSON 呈现为 (缩进+换行)
 func (c *Context) JSON(code int, obj interface{}) {
-	c.Render(code, render.JSON{Indented: IsDebugging(), Data: obj})
+	c.Render(code, render.JSON{Indented: IsDebugging(), ContentLength: c.centre.JSONContentLength, Data: obj})
+}
+
+// JSONError 附加错误到当前Context,并将其序列化为JSON写入.
+// 状态码由centre.RegisterErrorStatus()注册的映射决定,没有匹配时为500.
+func (c *Context) JSONError(err error) {
+	code := c.centre.errorStatuses.statusOf(err)
+	c.JSON(code, c.Error(err).JSON())
 }
 
 // AsciiJSON 将给定的结构序列化为JSON并使用ASCII格式写入.(随手设置了Content-Type).
 func (c *Context) AsciiJSON(code int, obj interface{}) {
-	c.Render(code, render.JSON{IsAscii: true, Data: obj})
+	c.Render(code, render.JSON{IsAscii: true, ContentLength: c.centre.JSONContentLength, Data: obj})
 }
 
 // PureJSON 将给定的结构序列化为JSON并写入(不使用unicode替换特殊字符).
 func (c *Context) PureJSON(code int, obj interface{}) {
-	c.Render(code, render.JSON{IsPrue: true, Data: obj})
+	c.Render(code, render.JSON{IsPrue: true, ContentLength: c.centre.JSONContentLength, Data: obj})
+}
+
+// Paginated 将分页列表序列化为{"data":items,"pagination":{...}}格式的JSON并写入,items为nil时输出空数组.
+func (c *Context) Paginated(code int, items interface{}, total, page, pageSize int) {
+	if items == nil {
+		items = []interface{}{}
+	}
+	c.JSON(code, Data{
+		"data":       items,
+		"pagination": NewPagination(total, page, pageSize),
+	})
+}
+
+// CSV 将records以text/csv写入.
+func (c *Context) CSV(code int, records [][]string) {
+	c.Render(code, render.CSV{Records: records})
+}
+
+// CSVAttachment 同CSV,但设置Content-Disposition使浏览器以filename下载.
+func (c *Context) CSVAttachment(code int, filename string, records [][]string) {
+	c.Render(code, render.CSV{Records: records, Filename: filename})
 }
 
 // XML 将给定的结构序列化为XML并写入response body.(随手设置了Content-Type)
@@ -752,6 +1057,11 @@ func (c *Context) XML(code int, obj interface{}) {
 	c.Render(code, render.XML{Data: obj})
 }
 
+// XMLWithHeader 同XML,但在数据前写入<?xml version="1.0" encoding="UTF-8"?>声明(部分严格的XML使用方需要).
+func (c *Context) XMLWithHeader(code int, obj interface{}) {
+	c.Render(code, render.XML{Data: obj, Header: true})
+}
+
 // String 将给定字符串写入响应正文.
 func (c *Context) String(code int, format string, values ...interface{}) {
 	c.Render(code, render.String{Format: format, Data: values})
@@ -766,6 +1076,45 @@ func (c *Context) Redirect(code int, location string) {
 	})
 }
 
+// ErrUnsafeRedirect SafeRedirect拒绝重定向到不允许的地址时返回的错误.
+var ErrUnsafeRedirect = errors.New("不允许的重定向地址")
+
+// SafeRedirect 同Redirect,但只允许重定向到相对路径、当前请求的Host或allowedHosts中的主机(防止开放重定向).
+// 地址不被允许时不重定向,以400中止并返回ErrUnsafeRedirect.
+// c.SafeRedirect(http.StatusFound, c.Query("next"), "accounts.example.com")
+func (c *Context) SafeRedirect(code int, location string, allowedHosts ...string) error {
+	if !isSafeRedirect(location, c.Request.Host, allowedHosts) {
+		c.AbortWithError(http.StatusBadRequest, ErrUnsafeRedirect).SetMeta(location) // nolint: errcheck
+		return ErrUnsafeRedirect
+	}
+	c.Redirect(code, location)
+	return nil
+}
+
+// isSafeRedirect 判断location是否为相对路径,或host|allowedHosts上的http(s)地址.
+// 浏览器会把"//host"和"/\host"当作其他主机的地址,因此也视为绝对地址.
+func isSafeRedirect(location, host string, allowedHosts []string) bool {
+	u, err := url.Parse(strings.ReplaceAll(location, "\\", "/"))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" && !strings.HasPrefix(u.Path, "//") {
+		return u.Opaque == ""
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	if strings.EqualFold(u.Host, host) {
+		return true
+	}
+	for _, allowed := range allowedHosts {
+		if strings.EqualFold(u.Host, allowed) || strings.EqualFold(u.Hostname(), allowed) {
+			return true
+		}
+	}
+	return false
+}
+
 // Data writes 将Data写入到body流并更新响应状态.
 func (c *Context) Data(code int, contentType string, data []byte) {
 	c.Render(code, render.Data{
@@ -789,6 +1138,12 @@ func (c *Context) File(filepath string) {
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
+// ServeContent 使用http.ServeContent写入content,支持Range请求(206)、Content-Type检测和条件请求(If-Modified-Since等).
+// 适用于不在磁盘上的可随机读取的内容(如内存中的视频),name用于按扩展名检测Content-Type.
+func (c *Context) ServeContent(name string, modtime time.Time, content io.ReadSeeker) {
+	http.ServeContent(c.Writer, c.Request, name, modtime, content)
+}
+
 // FileFromFS 从 http.FileSystem 将指定的文件写入body流.
 func (c *Context) FileFromFS(filepath string, fs http.FileSystem) {
 	defer func(old string) {
@@ -803,7 +1158,13 @@ func (c *Context) FileFromFS(filepath string, fs http.FileSystem) {
 // FileAttachment 以有效的方式将指定的文件写入body流
 // 在客户端，文件通常是用给定的文件名下载的
 func (c *Context) FileAttachment(filepath, filename string) {
-	c.Writer.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Writer.Header().Set("Content-Disposition", render.ContentDisposition("attachment", filename))
+	http.ServeFile(c.Writer, c.Request, filepath)
+}
+
+// FileInline 同FileAttachment,但使用inline,使浏览器可以直接预览文件(如PDF|图片).
+func (c *Context) FileInline(filepath, filename string) {
+	c.Writer.Header().Set("Content-Disposition", render.ContentDisposition("inline", filename))
 	http.ServeFile(c.Writer, c.Request, filepath)
 }
 
@@ -842,8 +1203,30 @@ type Negotiate struct {
 	Data     interface{}
 }
 
+// validate 检查提供的每种格式都有可用的数据,配置错误时立即panic.
+func (config Negotiate) validate() {
+	for _, offer := range config.Offered {
+		var custom interface{}
+		switch offer {
+		case binding.MIMEJSON:
+			custom = config.JSONData
+		case binding.MIMEHTML:
+			custom = config.HTMLData
+		case binding.MIMEXML:
+			custom = config.XMLData
+		default:
+			continue
+		}
+		if custom == nil && config.Data == nil {
+			panic("协议配置无效: 格式'" + offer + "'及Data均未提供数据")
+		}
+	}
+}
+
 // Negotiate 调用不同的渲染器(可调用的).
+// 如果提供的某种格式没有对应数据(且Data为空),将直接panic.
 func (c *Context) Negotiate(code int, config Negotiate) {
+	config.validate()
 	switch c.NegotiateFormat(config.Offered...) {
 	case binding.MIMEJSON:
 		data := chooseData(config.JSONData, config.Data)
@@ -874,18 +1257,7 @@ func (c *Context) NegotiateFormat(offered ...string) string {
 	}
 	for _, accepted := range c.Accepted {
 		for _, offer := range offered {
-			// 根据RFC 2616 和 RFC 2396, non-ASCII 不允许被用于headers中,
-			// 所以可以在字符串上迭代,不必转为[]rune
-			i := 0
-			for ; i < len(accepted); i++ {
-				if accepted[i] == '*' || offer[i] == '*' {
-					return offer
-				}
-				if accepted[i] != offer[i] {
-					break
-				}
-			}
-			if i == len(accepted) {
+			if matchAccept(accepted, offer) {
 				return offer
 			}
 		}
@@ -893,6 +1265,26 @@ func (c *Context) NegotiateFormat(offered ...string) string {
 	return ""
 }
 
+// AcceptsAll 按客户端的偏好顺序(q值)返回offered中所有可接受的格式.
+// 没有Accept header时按原顺序返回offered.
+func (c *Context) AcceptsAll(offered ...string) []string {
+	if c.Accepted == nil {
+		c.Accepted = parseAccept(c.requestHeader("Accept"))
+	}
+	if len(c.Accepted) == 0 {
+		return offered
+	}
+	out := make([]string, 0, len(offered))
+	for _, accepted := range c.Accepted {
+		for _, offer := range offered {
+			if matchAccept(accepted, offer) && !containsString(out, offer) {
+				out = append(out, offer)
+			}
+		}
+	}
+	return out
+}
+
 // SetAccepted 设置 Accept header data.
 func (c *Context) SetAccepted(formats ...string) {
 	c.Accepted = formats
@@ -0,0 +1,1173 @@
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lierbai/web/binding"
+)
+
+func init() {
+	SetMode(TestMode)
+}
+
+// performRequest 使用centre处理method path的请求并返回响应记录.
+func performRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// newTestContext 返回写入w的新Context,请求需要由调用者设置.
+func newTestContext(w http.ResponseWriter) *Context {
+	c := New().allocateContext()
+	c.writermem.reset(w)
+	c.reset()
+	return c
+}
+
+func TestContextFileDispositionRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(file, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{
+		"report.pdf",
+		`say "hi" \ now.txt`,
+		"é'(a)=b:c@d.pdf",
+		"报表 2024;x,y*z$&+.csv",
+	}
+	r := New()
+	r.GET("/attachment", func(c *Context) { c.FileAttachment(file, c.Query("name")) })
+	r.GET("/inline", func(c *Context) { c.FileInline(file, c.Query("name")) })
+
+	for _, kind := range []string{"attachment", "inline"} {
+		for _, name := range names {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/"+kind, nil)
+			q := req.URL.Query()
+			q.Set("name", name)
+			req.URL.RawQuery = q.Encode()
+			r.ServeHTTP(w, req)
+
+			header := w.Header().Get("Content-Disposition")
+			disposition, params, err := mime.ParseMediaType(header)
+			if err != nil {
+				t.Fatalf("%s %q: ParseMediaType(%q) error: %v", kind, name, header, err)
+			}
+			if disposition != kind {
+				t.Errorf("%s %q: disposition = %q", kind, name, disposition)
+			}
+			if params["filename"] != name {
+				t.Errorf("%s %q: filename round-trip = %q (header %q)", kind, name, params["filename"], header)
+			}
+		}
+	}
+}
+
+func TestContextShouldBindQueryForNonGet(t *testing.T) {
+	type filter struct {
+		ID   int    `form:"id" json:"id"`
+		Name string `form:"name" json:"name"`
+	}
+	newContext := func(method, target, contentType, body string) *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			c.Request.Header.Set("Content-Type", contentType)
+		}
+		return c
+	}
+
+	var f filter
+	c := newContext(http.MethodDelete, "/items?id=7&name=old", "", "")
+	if err := c.ShouldBindQueryForNonGet(&f); err != nil || f.ID != 7 || f.Name != "old" {
+		t.Errorf("DELETE query binding = %+v, %v", f, err)
+	}
+
+	f = filter{}
+	c = newContext(http.MethodDelete, "/items?id=7", MIMEJSON, `{"id":9,"name":"body"}`)
+	if err := c.ShouldBindQueryForNonGet(&f); err != nil || f.ID != 9 || f.Name != "body" {
+		t.Errorf("DELETE with JSON body = %+v, %v", f, err)
+	}
+
+	f = filter{}
+	c = newContext(http.MethodGet, "/items?id=3", "", "")
+	if err := c.ShouldBindQueryForNonGet(&f); err != nil || f.ID != 3 {
+		t.Errorf("GET binding = %+v, %v", f, err)
+	}
+}
+
+func TestContextClientIPTrustForwardedOnlyOnTLS(t *testing.T) {
+	newRequest := func() *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.centre.TrustForwardedOnlyOnTLS = true
+		c.centre.TrustedPlatform = PlatformCloudflare
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		return c
+	}
+
+	// TLS连接信任转发headers,TrustedPlatform优先
+	c := newRequest()
+	c.Request.TLS = &tls.ConnectionState{}
+	c.Request.Header.Set("X-Forwarded-For", "203.0.113.9")
+	if ip := c.ClientIP(); ip != "203.0.113.9" {
+		t.Errorf("TLS X-Forwarded-For ClientIP = %q", ip)
+	}
+	c.Request.Header.Set(PlatformCloudflare, "198.51.100.4")
+	if ip := c.ClientIP(); ip != "198.51.100.4" {
+		t.Errorf("TLS TrustedPlatform ClientIP = %q", ip)
+	}
+
+	// 明文连接忽略所有携带客户端IP的headers
+	c = newRequest()
+	c.centre.AppCentre = true
+	c.Request.Header.Set("X-Forwarded-For", "203.0.113.9")
+	c.Request.Header.Set(PlatformCloudflare, "198.51.100.4")
+	c.Request.Header.Set("X-Appengine-Remote-Addr", "192.0.2.8")
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("plaintext ClientIP = %q, want 10.0.0.1", ip)
+	}
+}
+
+func TestContextParamInt(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	c.Params = Params{{Key: "id", Value: "42"}, {Key: "big", Value: "9007199254740993"}, {Key: "name", Value: "abc"}}
+
+	if v, ok := c.ParamInt("id"); !ok || v != 42 {
+		t.Errorf("ParamInt(id) = %d, %v", v, ok)
+	}
+	if v, ok := c.ParamInt64("big"); !ok || v != 9007199254740993 {
+		t.Errorf("ParamInt64(big) = %d, %v", v, ok)
+	}
+	for _, key := range []string{"name", "missing"} {
+		if v, ok := c.ParamInt(key); ok || v != 0 {
+			t.Errorf("ParamInt(%s) = %d, %v, want 0, false", key, v, ok)
+		}
+		if v, ok := c.ParamInt64(key); ok || v != 0 {
+			t.Errorf("ParamInt64(%s) = %d, %v, want 0, false", key, v, ok)
+		}
+	}
+}
+
+var errTestNotFound = errors.New("record not found")
+
+func TestContextJSONError(t *testing.T) {
+	r := New()
+	r.RegisterErrorStatus(errTestNotFound, http.StatusNotFound)
+	r.GET("/known", func(c *Context) { c.JSONError(fmt.Errorf("load user: %w", errTestNotFound)) })
+	r.GET("/unknown", func(c *Context) { c.JSONError(errors.New("disk on fire")) })
+
+	tests := []struct {
+		path string
+		code int
+		msg  string
+	}{
+		{"/known", http.StatusNotFound, "load user: record not found"},
+		{"/unknown", http.StatusInternalServerError, "disk on fire"},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, http.MethodGet, tt.path)
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode %q: %v", tt.path, w.Body.String(), err)
+		}
+		if w.Code != tt.code || body["error"] != tt.msg {
+			t.Errorf("%s = %d %v, want %d %q", tt.path, w.Code, body, tt.code, tt.msg)
+		}
+	}
+}
+
+func TestContextNegotiateConfigValidation(t *testing.T) {
+	newContext := func(accept string) (*Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c := newTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", accept)
+		return c, w
+	}
+
+	// 客户端只接受JSON,但XML缺少数据的配置错误仍会立即报告
+	c, _ := newContext(MIMEJSON)
+	func() {
+		defer func() {
+			msg, _ := recover().(string)
+			if !strings.Contains(msg, MIMEXML) {
+				t.Errorf("panic = %q, want configuration error naming %s", msg, MIMEXML)
+			}
+		}()
+		c.Negotiate(http.StatusOK, Negotiate{Offered: []string{MIMEJSON, MIMEXML}, JSONData: Data{"a": 1}})
+	}()
+
+	c, w := newContext(MIMEXML)
+	c.Negotiate(http.StatusOK, Negotiate{Offered: []string{MIMEJSON, MIMEXML}, Data: Data{"a": 1}})
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), MIMEXML) {
+		t.Errorf("fallback Data response = %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestContextPostFormTypedE(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	body := url.Values{"n": {"12"}, "bad": {"x1"}, "flag": {"true"}, "nope": {"maybe"}}.Encode()
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", MIMEPOSTForm)
+
+	if v, err := c.PostFormIntE("n"); err != nil || v != 12 {
+		t.Errorf("PostFormIntE(n) = %d, %v", v, err)
+	}
+	if v, err := c.PostFormBoolE("flag"); err != nil || !v {
+		t.Errorf("PostFormBoolE(flag) = %v, %v", v, err)
+	}
+	if _, err := c.PostFormIntE("bad"); err == nil {
+		t.Error("PostFormIntE(bad) returned no error")
+	}
+	if _, err := c.PostFormBoolE("nope"); err == nil {
+		t.Error("PostFormBoolE(nope) returned no error")
+	}
+	if _, err := c.PostFormIntE("missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("PostFormIntE(missing) error = %v", err)
+	}
+	if _, err := c.PostFormBoolE("missing"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("PostFormBoolE(missing) error = %v", err)
+	}
+}
+
+func TestContextClientIPTrustedPlatform(t *testing.T) {
+	newContext := func(platform string) *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.centre.TrustedPlatform = platform
+		c.centre.ForwardedByClientIP = false
+		c.centre.AppCentre = false
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		c.Request.Header.Set(PlatformCloudflare, "198.51.100.4")
+		c.Request.Header.Set(PlatformGoogleAppEngine, "192.0.2.8")
+		return c
+	}
+
+	if ip := newContext(PlatformCloudflare).ClientIP(); ip != "198.51.100.4" {
+		t.Errorf("Cloudflare ClientIP = %q", ip)
+	}
+	if ip := newContext(PlatformGoogleAppEngine).ClientIP(); ip != "192.0.2.8" {
+		t.Errorf("App Engine ClientIP = %q", ip)
+	}
+	// 未设置平台时不信任平台headers
+	if ip := newContext("").ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("untrusted ClientIP = %q, want 10.0.0.1", ip)
+	}
+	// 平台header缺失时回退到RemoteAddr
+	c := newContext(PlatformCloudflare)
+	c.Request.Header.Del(PlatformCloudflare)
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("missing platform header ClientIP = %q, want 10.0.0.1", ip)
+	}
+}
+
+func TestContextDefaultSameSite(t *testing.T) {
+	r := New()
+	r.DefaultSameSite = http.SameSiteLaxMode
+	r.GET("/default", func(c *Context) { c.SetCookie("a", "1", 60, "/", "", false, true) })
+	r.GET("/override", func(c *Context) {
+		c.SetSameSite(http.SameSiteStrictMode)
+		c.SetCookie("a", "1", 60, "/", "", false, true)
+	})
+
+	tests := map[string]string{"/default": "SameSite=Lax", "/override": "SameSite=Strict"}
+	for path, want := range tests {
+		// 两次请求确认上下文复用时默认值会被重新应用
+		for i := 0; i < 2; i++ {
+			w := performRequest(r, http.MethodGet, path)
+			if cookie := w.Header().Get("Set-Cookie"); !strings.Contains(cookie, want) {
+				t.Errorf("%s Set-Cookie = %q, want %s", path, cookie, want)
+			}
+		}
+	}
+}
+
+func TestContextGetRawDataThenBind(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"web"}`))
+	c.Request.Header.Set("Content-Type", MIMEJSON)
+
+	raw, err := c.GetRawData()
+	if err != nil || string(raw) != `{"name":"web"}` {
+		t.Fatalf("GetRawData = %q, %v", raw, err)
+	}
+	again, err := c.GetRawData()
+	if err != nil || string(again) != string(raw) {
+		t.Errorf("second GetRawData = %q, %v", again, err)
+	}
+	var obj struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&obj); err != nil || obj.Name != "web" {
+		t.Errorf("ShouldBindJSON after GetRawData = %+v, %v", obj, err)
+	}
+}
+
+func TestContextTeeBody(t *testing.T) {
+	const body = `{"name":"web","tags":["a","b"]}`
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", MIMEJSON)
+
+	var audit bytes.Buffer
+	c.TeeBody(&audit)
+	var obj struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&obj); err != nil || obj.Name != "web" || len(obj.Tags) != 2 {
+		t.Fatalf("ShouldBindJSON = %+v, %v", obj, err)
+	}
+	// json.Decoder可能没有读到EOF,读完剩余部分后tee目标应收到完整的请求体
+	io.Copy(io.Discard, c.Request.Body) // nolint: errcheck
+	if audit.String() != body {
+		t.Errorf("tee target = %q, want %q", audit.String(), body)
+	}
+	if err := c.Request.Body.Close(); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+}
+
+func TestContextAcceptsAll(t *testing.T) {
+	offered := []string{MIMEJSON, MIMEXML, MIMEHTML, MIMEPlain}
+	tests := []struct {
+		accept string
+		want   []string
+	}{
+		{"text/html;q=0.5, application/xml, application/json;q=0.8", []string{MIMEXML, MIMEJSON, MIMEHTML}},
+		{"text/*;q=0.3, application/json", []string{MIMEJSON, MIMEHTML, MIMEPlain}},
+		{"image/png", []string{}},
+		{"", offered},
+	}
+	for _, tt := range tests {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Accept", tt.accept)
+		if got := c.AcceptsAll(offered...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Accept %q: AcceptsAll = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+// newUploadRequest 构造在field下上传files(文件名->内容,按names顺序)的multipart请求.
+func newUploadRequest(t *testing.T, field string, names []string, files map[string]string) *http.Request {
+	t.Helper()
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	for _, name := range names {
+		fw, err := mw.CreateFormFile(field, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(fw, files[name]) // nolint: errcheck
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestContextFormFiles(t *testing.T) {
+	names := []string{"a.txt", "b.txt", "c.txt"}
+	files := map[string]string{"a.txt": "first", "b.txt": "second", "c.txt": "third"}
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = newUploadRequest(t, "docs", names, files)
+
+	got, err := c.FormFiles("docs")
+	if err != nil || len(got) != 3 {
+		t.Fatalf("FormFiles(docs) = %d files, %v", len(got), err)
+	}
+	for i, fh := range got {
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if fh.Filename != names[i] || string(data) != files[names[i]] {
+			t.Errorf("file %d = %s %q", i, fh.Filename, data)
+		}
+	}
+	if _, err := c.FormFiles("missing"); err != http.ErrMissingFile {
+		t.Errorf("FormFiles(missing) error = %v, want http.ErrMissingFile", err)
+	}
+
+	var form struct {
+		Docs []*multipart.FileHeader `form:"docs"`
+	}
+	c = newTestContext(httptest.NewRecorder())
+	c.Request = newUploadRequest(t, "docs", names, files)
+	if err := c.ShouldBind(&form); err != nil || len(form.Docs) != 3 {
+		t.Errorf("ShouldBind slice field = %d files, %v", len(form.Docs), err)
+	}
+}
+
+func TestContextShouldBindUriCatchAll(t *testing.T) {
+	type files struct {
+		Path     string   `uri:"path" binding:"required"`
+		Segments []string `uri:"path"`
+		Bucket   string   `uri:"bucket"`
+	}
+	var got files
+	r := New()
+	r.GET("/b/:bucket/*path", func(c *Context) {
+		if err := c.ShouldBindUri(&got); err != nil {
+			t.Errorf("ShouldBindUri error: %v", err)
+		}
+	})
+
+	performRequest(r, http.MethodGet, "/b/media/img/2024/cat.png")
+	if got.Bucket != "media" || got.Path != "/img/2024/cat.png" {
+		t.Errorf("bound %+v", got)
+	}
+	if want := []string{"img", "2024", "cat.png"}; !reflect.DeepEqual(got.Segments, want) {
+		t.Errorf("Segments = %v, want %v", got.Segments, want)
+	}
+}
+
+func TestContextQueryFirst(t *testing.T) {
+	keys := []string{"q", "query", "search"}
+	tests := []struct {
+		query, value, key string
+		ok                bool
+	}{
+		{"q=a&query=b&search=c", "a", "q", true},
+		{"search=c&query=", "", "query", true},
+		{"other=x", "", "", false},
+	}
+	for _, tt := range tests {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		value, key, ok := c.QueryFirst(keys...)
+		if value != tt.value || key != tt.key || ok != tt.ok {
+			t.Errorf("?%s: QueryFirst = %q, %q, %v, want %q, %q, %v", tt.query, value, key, ok, tt.value, tt.key, tt.ok)
+		}
+	}
+}
+
+func TestContextClientIPRemoteIPHeadersOrder(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	c.centre.AppCentre = false
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	c.Request.Header.Set("X-Forwarded-For", "203.0.113.9, 10.0.0.2")
+	c.Request.Header.Set("True-Client-IP", "198.51.100.4")
+	c.Request.Header.Set("X-Real-Ip", "192.0.2.8")
+
+	// 默认顺序:X-Forwarded-For的第一个值
+	if ip := c.ClientIP(); ip != "203.0.113.9" {
+		t.Errorf("default order ClientIP = %q", ip)
+	}
+	c.centre.RemoteIPHeaders = []string{"True-Client-IP", "X-Forwarded-For"}
+	if ip := c.ClientIP(); ip != "198.51.100.4" {
+		t.Errorf("custom order ClientIP = %q", ip)
+	}
+	c.centre.RemoteIPHeaders = []string{"X-Missing", "X-Real-Ip"}
+	if ip := c.ClientIP(); ip != "192.0.2.8" {
+		t.Errorf("skipping absent header ClientIP = %q", ip)
+	}
+	c.centre.ForwardedByClientIP = false
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("ForwardedByClientIP=false ClientIP = %q", ip)
+	}
+}
+
+func TestContextIndentedJSONWith(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.IndentedJSONWith(http.StatusOK, "\t", Data{"a": 1})
+	if got, want := w.Body.String(), "{\n\t\"a\": 1\n}"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestContextBindAndValidate(t *testing.T) {
+	type signupForm struct {
+		Name  string `form:"name" binding:"required"`
+		Email string `form:"email" binding:"required,email"`
+		Age   int    `form:"age" binding:"min=18"`
+	}
+	newFormContext := func(body string) *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", MIMEPOSTForm)
+		return c
+	}
+
+	c := newFormContext("email=bad&age=16")
+	var form signupForm
+	fields, ok := c.BindAndValidate(&form)
+	if ok {
+		t.Fatal("BindAndValidate ok = true, want false")
+	}
+	want := map[string]string{
+		"Name":  "Name未通过'required'验证",
+		"Email": "Email未通过'email'验证",
+		"Age":   "Age未通过'min=18'验证",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+	if c.Writer.Written() {
+		t.Error("BindAndValidate wrote a response")
+	}
+
+	c = newFormContext("name=bob&email=bob@example.com&age=20")
+	if fields, ok := c.BindAndValidate(&form); !ok || fields != nil {
+		t.Errorf("BindAndValidate = %v, %v, want nil, true", fields, ok)
+	}
+}
+
+func TestContextMustBindJSON(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	newJSONContext := func(body string) *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", MIMEJSON)
+		return c
+	}
+
+	var p payload
+	newJSONContext(`{"name":"web"}`).MustBindJSON(&p)
+	if p.Name != "web" {
+		t.Errorf("Name = %q, want web", p.Name)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("MustBindJSON did not panic on malformed JSON")
+			}
+		}()
+		c := newJSONContext(`{"name":`)
+		c.MustBindJSON(&p)
+	}()
+
+	r := New()
+	r.Use(RecoveryWithWriter(io.Discard))
+	r.POST("/", func(c *Context) {
+		var p payload
+		c.MustBindJSON(&p)
+		c.String(http.StatusOK, p.Name)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`not json`))
+	req.Header.Set("Content-Type", MIMEJSON)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 from Recovery", w.Code)
+	}
+}
+
+func TestGetGeneric(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	c := newTestContext(httptest.NewRecorder())
+	c.Set("user", user{ID: 1, Name: "web"})
+	c.Set("count", 42)
+
+	if u, ok := Get[user](c, "user"); !ok || u != (user{ID: 1, Name: "web"}) {
+		t.Errorf("Get[user] = %v, %v", u, ok)
+	}
+	if n, ok := Get[int](c, "count"); !ok || n != 42 {
+		t.Errorf("Get[int] = %v, %v", n, ok)
+	}
+	if s, ok := Get[string](c, "count"); ok || s != "" {
+		t.Errorf("Get[string] on int = %q, %v, want zero value and false", s, ok)
+	}
+	if u, ok := Get[*user](c, "user"); ok || u != nil {
+		t.Errorf("Get[*user] on user = %v, %v, want nil and false", u, ok)
+	}
+	if n, ok := Get[int](c, "missing"); ok || n != 0 {
+		t.Errorf("Get[int] on missing key = %v, %v", n, ok)
+	}
+}
+
+func TestContextSetMaxMultipartMemory(t *testing.T) {
+	content := strings.Repeat("x", 4096)
+	onDisk := func(c *Context) bool {
+		t.Helper()
+		fh, err := c.FormFile("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if data, _ := io.ReadAll(f); string(data) != content {
+			t.Errorf("file content length = %d, want %d", len(data), len(content))
+		}
+		_, isFile := f.(*os.File)
+		return isFile
+	}
+	newContext := func() *Context {
+		c := newTestContext(httptest.NewRecorder())
+		c.Request = newUploadRequest(t, "file", []string{"big.bin"}, map[string]string{"big.bin": content})
+		return c
+	}
+
+	c := newContext()
+	if onDisk(c) {
+		t.Error("default MaxMultipartMemory: 4KB upload spilled to disk, want in memory")
+	}
+
+	c = newContext()
+	c.SetMaxMultipartMemory(1024)
+	if !onDisk(c) {
+		t.Error("SetMaxMultipartMemory(1024): 4KB upload kept in memory, want spilled to disk")
+	}
+	c.Request.MultipartForm.RemoveAll() // nolint: errcheck
+
+	if c := newContext(); c.maxMemory != c.centre.MaxMultipartMemory {
+		t.Errorf("maxMemory after reset = %d, want centre default %d", c.maxMemory, c.centre.MaxMultipartMemory)
+	}
+}
+
+func TestContextServeContent(t *testing.T) {
+	const video = "0123456789abcdef"
+	modtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := New()
+	r.GET("/clip.mp4", func(c *Context) {
+		c.ServeContent("clip.mp4", modtime, strings.NewReader(video))
+	})
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/clip.mp4", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("", "")
+	if w.Code != http.StatusOK || w.Body.String() != video {
+		t.Errorf("full: %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "video/mp4" {
+		t.Errorf("full: Content-Type = %q, want video/mp4", got)
+	}
+	if got := w.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("full: Accept-Ranges = %q", got)
+	}
+
+	w = serve("Range", "bytes=4-7")
+	if w.Code != http.StatusPartialContent || w.Body.String() != "4567" {
+		t.Errorf("range: %d %q, want 206 \"4567\"", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Range"); got != "bytes 4-7/16" {
+		t.Errorf("range: Content-Range = %q", got)
+	}
+
+	w = serve("If-Modified-Since", modtime.Format(http.TimeFormat))
+	if w.Code != http.StatusNotModified {
+		t.Errorf("conditional: status = %d, want 304", w.Code)
+	}
+}
+
+func TestContextAbortToHandler(t *testing.T) {
+	loginPage := func(c *Context) {
+		c.Data(http.StatusUnauthorized, "text/html; charset=utf-8", []byte("<h1>请登录</h1>"))
+	}
+	var steps []string
+	r := New()
+	r.Use(func(c *Context) {
+		if c.GetHeader("Cookie") == "" {
+			c.AbortToHandler(loginPage)
+			steps = append(steps, fmt.Sprintf("auth aborted=%v", c.IsAborted()))
+			return
+		}
+		c.Next()
+	}, func(c *Context) {
+		steps = append(steps, "audit")
+	})
+	r.GET("/dashboard", func(c *Context) {
+		steps = append(steps, "dashboard")
+		c.String(http.StatusOK, "dashboard")
+	})
+
+	w := performRequest(r, http.MethodGet, "/dashboard")
+	if w.Code != http.StatusUnauthorized || w.Body.String() != "<h1>请登录</h1>" {
+		t.Errorf("GET /dashboard = %d %q, want the login page", w.Code, w.Body.String())
+	}
+	if ctype := w.Header().Get("Content-Type"); ctype != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	if want := []string{"auth aborted=true"}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+
+	steps = nil
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Header.Set("Cookie", "session=1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !reflect.DeepEqual(steps, []string{"audit", "dashboard"}) {
+		t.Errorf("authenticated: %d, steps %q", w.Code, steps)
+	}
+}
+
+func TestContextMustBindWithValidationMeta(t *testing.T) {
+	type signup struct {
+		Name string `json:"name" binding:"required"`
+		Age  int    `json:"age" binding:"min=18"`
+	}
+	bind := func(body string) (*Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c := newTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", MIMEJSON)
+		var s signup
+		if err := c.MustBindWith(&s, binding.JSON); err == nil {
+			t.Fatalf("MustBindWith(%s) = nil, want error", body)
+		}
+		return c, w
+	}
+
+	c, w := bind(`{"age":16}`)
+	if w.Code != http.StatusBadRequest || !c.IsAborted() {
+		t.Errorf("status = %d, aborted = %v", w.Code, c.IsAborted())
+	}
+	e := c.Errors.Last()
+	if e == nil || e.Type != ErrorTypeBind {
+		t.Fatalf("last error = %#v, want ErrorTypeBind", e)
+	}
+	want := map[string]string{"Name": "required", "Age": "min"}
+	if !reflect.DeepEqual(e.Meta, want) {
+		t.Errorf("Meta = %#v, want %#v", e.Meta, want)
+	}
+
+	c, _ = bind(`{"age":`)
+	if e := c.Errors.Last(); e == nil || e.Meta != nil {
+		t.Errorf("malformed JSON: last error = %#v, want nil Meta", e)
+	}
+}
+
+func TestContextPaginated(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	tests := []struct {
+		name                  string
+		items                 interface{}
+		total, page, pageSize int
+		want                  string
+	}{
+		{
+			"typical page", []item{{21}, {22}}, 42, 3, 10,
+			`{"data":[{"id":21},{"id":22}],"pagination":{"page":3,"pageSize":10,"total":42,"totalPages":5}}`,
+		},
+		{
+			"exact multiple", []item{{1}}, 20, 1, 10,
+			`{"data":[{"id":1}],"pagination":{"page":1,"pageSize":10,"total":20,"totalPages":2}}`,
+		},
+		{
+			"empty result", nil, 0, 1, 20,
+			`{"data":[],"pagination":{"page":1,"pageSize":20,"total":0,"totalPages":0}}`,
+		},
+		{
+			"zero page size", []item{}, 5, 1, 0,
+			`{"data":[],"pagination":{"page":1,"pageSize":0,"total":5,"totalPages":0}}`,
+		},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c := newTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/items", nil)
+		c.Paginated(http.StatusOK, tt.items, tt.total, tt.page, tt.pageSize)
+		if w.Code != http.StatusOK || w.Body.String() != tt.want {
+			t.Errorf("%s: %d %s\nwant %s", tt.name, w.Code, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestContextSetWithTTL(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	c.SetWithTTL("fresh", "v1", time.Hour)
+	c.SetWithTTL("stale", "v2", time.Millisecond)
+	c.SetWithTTL("renewed", "v3", time.Millisecond)
+	c.Set("renewed", "v4") // Set清除过期时间
+	time.Sleep(5 * time.Millisecond)
+
+	if v, ok := c.Get("fresh"); !ok || v != "v1" {
+		t.Errorf("Get(fresh) = %v, %v, want v1, true", v, ok)
+	}
+	if v, ok := c.Get("stale"); ok || v != nil {
+		t.Errorf("Get(stale) = %v, %v, want nil, false", v, ok)
+	}
+	if v, ok := c.Get("renewed"); !ok || v != "v4" {
+		t.Errorf("Get(renewed) = %v, %v, want v4, true", v, ok)
+	}
+	if s := c.GetString("stale"); s != "" {
+		t.Errorf("GetString(stale) = %q, want empty", s)
+	}
+
+	cp := c.Copy()
+	if _, ok := cp.Get("fresh"); !ok {
+		t.Error("Copy lost the unexpired key")
+	}
+	if _, ok := cp.Get("stale"); ok {
+		t.Error("Copy resurrected the expired key")
+	}
+
+	c.reset()
+	c.Set("stale", "v5")
+	if v, ok := c.Get("stale"); !ok || v != "v5" {
+		t.Errorf("after reset Get(stale) = %v, %v, want v5, true", v, ok)
+	}
+}
+
+func TestContextClientIPForwarded(t *testing.T) {
+	tests := []struct {
+		forwarded, want string
+	}{
+		{`for="[2001:db8::1]";proto=https`, "2001:db8::1"},
+		{`for="[2001:db8::1]:4711"`, "2001:db8::1"},
+		{`for=192.0.2.43, for=198.51.100.17;by=203.0.113.60`, "192.0.2.43"},
+		{`proto=http;For="192.0.2.60:8080";by=203.0.113.43, for=10.0.0.2`, "192.0.2.60"},
+		{`for=unknown, for=192.0.2.1`, "203.0.113.9"}, // 第一跳未知,回退到X-Forwarded-For
+		{`for=_hidden`, "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		c := newTestContext(httptest.NewRecorder())
+		c.centre.AppCentre = false
+		c.centre.RemoteIPHeaders = []string{"Forwarded", "X-Forwarded-For"}
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		c.Request.Header.Set("Forwarded", tt.forwarded)
+		c.Request.Header.Set("X-Forwarded-For", "203.0.113.9")
+		if ip := c.ClientIP(); ip != tt.want {
+			t.Errorf("Forwarded: %s: ClientIP = %q, want %q", tt.forwarded, ip, tt.want)
+		}
+	}
+
+	c := newTestContext(httptest.NewRecorder())
+	c.centre.AppCentre = false
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.RemoteAddr = "10.0.0.1:1234"
+	c.Request.Header.Set("Forwarded", "for=192.0.2.43")
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("default RemoteIPHeaders: ClientIP = %q, Forwarded must be opt-in", ip)
+	}
+
+	c.centre.RemoteIPHeaders = []string{"Forwarded"}
+	c.centre.TrustForwardedOnlyOnTLS = true
+	if ip := c.ClientIP(); ip != "10.0.0.1" {
+		t.Errorf("untrusted plain HTTP: ClientIP = %q, want the peer address", ip)
+	}
+}
+
+func TestContextWritten(t *testing.T) {
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if c.Written() {
+		t.Error("Written() = true before any write")
+	}
+	c.Status(http.StatusCreated)
+	if c.Written() {
+		t.Error("Written() = true after c.Status only, headers are not sent yet")
+	}
+	c.Writer.WriteHeaderNow()
+	if !c.Written() {
+		t.Error("Written() = false after WriteHeaderNow")
+	}
+
+	c = newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Writer.Write([]byte("body")) // nolint: errcheck
+	if !c.Written() {
+		t.Error("Written() = false after c.Writer.Write")
+	}
+
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		if !c.Written() {
+			c.JSON(http.StatusNotFound, Data{"error": "empty"})
+		}
+	})
+	r.GET("/empty", func(c *Context) {})
+	r.GET("/ok", func(c *Context) { c.String(http.StatusOK, "ok") })
+	if w := performRequest(r, http.MethodGet, "/empty"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /empty = %d, want the fallback 404", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/ok"); w.Body.String() != "ok" {
+		t.Errorf("GET /ok body = %q, guard rendered over the response", w.Body.String())
+	}
+}
+
+func TestCentreBindErrorRenderer(t *testing.T) {
+	type payload struct {
+		Name string `json:"name" binding:"required"`
+	}
+	newRouter := func(renderer func(*Context, error)) *Centre {
+		r := New()
+		r.BindErrorRenderer = renderer
+		r.POST("/", func(c *Context) {
+			var p payload
+			if c.BindJSON(&p) != nil {
+				return
+			}
+			c.String(http.StatusOK, p.Name)
+		})
+		return r
+	}
+	post := func(r *Centre, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", MIMEJSON)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var bindErrs int
+	r := newRouter(func(c *Context, err error) {
+		bindErrs = len(c.Errors.ByType(ErrorTypeBind))
+		c.JSON(http.StatusUnprocessableEntity, Data{"error": err.Error()})
+	})
+	w := post(r, `{}`)
+	if w.Code != http.StatusUnprocessableEntity || !strings.HasPrefix(w.Body.String(), `{"error":"`) {
+		t.Errorf("custom renderer: %d %s", w.Code, w.Body.String())
+	}
+	if ctype := w.Header().Get("Content-Type"); !strings.HasPrefix(ctype, MIMEJSON) {
+		t.Errorf("custom renderer: Content-Type = %q", ctype)
+	}
+	if bindErrs != 1 {
+		t.Errorf("custom renderer saw %d bind errors in c.Errors, want 1", bindErrs)
+	}
+	if w := post(r, `{"name":"web"}`); w.Code != http.StatusOK || w.Body.String() != "web" {
+		t.Errorf("valid payload with renderer: %d %q", w.Code, w.Body.String())
+	}
+
+	w = post(newRouter(nil), `{}`)
+	if w.Code != http.StatusBadRequest || w.Body.Len() != 0 {
+		t.Errorf("default: %d %q, want 400 with empty body", w.Code, w.Body.String())
+	}
+}
+
+func TestContextClearCookie(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.SetCookie("theme", "dark", 3600, "/", "", false, false)
+	c.ClearCookie("session", "/app", "example.com")
+
+	headers := w.Header().Values("Set-Cookie")
+	if len(headers) != 2 {
+		t.Fatalf("Set-Cookie = %q, want 2 headers", headers)
+	}
+	cleared, err := http.ParseSetCookie(headers[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cleared.Name != "session" || cleared.Value != "" || cleared.Path != "/app" || cleared.Domain != "example.com" {
+		t.Errorf("cleared cookie = %+v", cleared)
+	}
+	// http.Cookie的MaxAge<0序列化为"Max-Age=0",表示立即删除
+	if !strings.Contains(headers[1], "Max-Age=0") || cleared.MaxAge >= 0 {
+		t.Errorf("Set-Cookie %q: want Max-Age=0 (parsed MaxAge %d)", headers[1], cleared.MaxAge)
+	}
+	if !cleared.Expires.Before(time.Now()) {
+		t.Errorf("Expires = %v, want a time in the past", cleared.Expires)
+	}
+
+	w = httptest.NewRecorder()
+	c = newTestContext(w)
+	c.ClearCookie("session", "", "")
+	if got := w.Header().Get("Set-Cookie"); !strings.Contains(got, "Path=/;") {
+		t.Errorf("default path: Set-Cookie = %q, want Path=/", got)
+	}
+}
+
+func TestCentreJSONContentLength(t *testing.T) {
+	renderers := map[string]func(c *Context){
+		"JSON":         func(c *Context) { c.JSON(http.StatusOK, Data{"msg": "hi"}) },
+		"AsciiJSON":    func(c *Context) { c.AsciiJSON(http.StatusOK, Data{"msg": "中文"}) },
+		"PureJSON":     func(c *Context) { c.PureJSON(http.StatusOK, Data{"html": "<b>"}) },
+		"IndentedJSON": func(c *Context) { c.IndentedJSON(http.StatusOK, Data{"msg": "hi"}) },
+	}
+	for _, enabled := range []bool{true, false} {
+		for name, render := range renderers {
+			w := httptest.NewRecorder()
+			c := newTestContext(w)
+			c.centre.JSONContentLength = enabled
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			render(c)
+
+			got := w.Header().Get("Content-Length")
+			if enabled && got != fmt.Sprint(w.Body.Len()) {
+				t.Errorf("%s enabled: Content-Length = %q, body is %d bytes", name, got, w.Body.Len())
+			}
+			if !enabled && got != "" {
+				t.Errorf("%s disabled: Content-Length = %q, want absent", name, got)
+			}
+		}
+	}
+}
+
+func TestContextSafeRedirect(t *testing.T) {
+	r := New()
+	r.GET("/login", func(c *Context) {
+		c.SafeRedirect(http.StatusFound, c.Query("next"), "accounts.example.com") // nolint: errcheck
+	})
+
+	tests := []struct {
+		next     string
+		wantCode int
+	}{
+		{"/dashboard?tab=1", http.StatusFound},
+		{"profile", http.StatusFound},
+		{"https://accounts.example.com/settings", http.StatusFound},
+		{"http://accounts.example.com:8443/", http.StatusFound},
+		{"http://example.com/home", http.StatusFound}, // 当前请求的Host
+		{"https://evil.com/", http.StatusBadRequest},
+		{"//evil.com/", http.StatusBadRequest},
+		{"/\\evil.com", http.StatusBadRequest},
+		{"https://accounts.example.com.evil.com/", http.StatusBadRequest},
+		{"javascript:alert(1)", http.StatusBadRequest},
+		{"ftp://accounts.example.com/", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, http.MethodGet, "/login?"+url.Values{"next": {tt.next}}.Encode())
+		if w.Code != tt.wantCode {
+			t.Errorf("next=%q: status = %d, want %d", tt.next, w.Code, tt.wantCode)
+		}
+		location := w.Header().Get("Location")
+		if tt.wantCode == http.StatusBadRequest && location != "" {
+			t.Errorf("next=%q: Location = %q, want no redirect", tt.next, location)
+		}
+		if tt.wantCode == http.StatusFound && location == "" {
+			t.Errorf("next=%q: no Location header", tt.next)
+		}
+	}
+
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if err := c.SafeRedirect(http.StatusFound, "https://evil.com/"); err != ErrUnsafeRedirect {
+		t.Errorf("SafeRedirect error = %v, want ErrUnsafeRedirect", err)
+	}
+	if !c.IsAborted() || c.Errors.Last() == nil || c.Errors.Last().Meta != "https://evil.com/" {
+		t.Errorf("aborted = %v, last error = %#v", c.IsAborted(), c.Errors.Last())
+	}
+}
+
+func TestContextCSVAttachment(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := newTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/export", nil)
+	c.CSVAttachment(http.StatusOK, "users.csv", [][]string{{"id", "name"}, {"1", "web"}})
+
+	if w.Code != http.StatusOK || w.Body.String() != "id,name\n1,web\n" {
+		t.Errorf("CSVAttachment = %d %q", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="users.csv"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+}
+
+func TestContextShouldBindJSONAndQuery(t *testing.T) {
+	var req struct {
+		Page int    `form:"page" json:"page"`
+		Name string `form:"name" json:"name"`
+	}
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPut, "/items?page=4&name=query", strings.NewReader(`{"name":"body"}`))
+	c.Request.Header.Set("Content-Type", MIMEJSON)
+	if err := c.ShouldBindJSONAndQuery(&req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Page != 4 || req.Name != "body" {
+		t.Errorf("bound %+v, want page from the query and name from the body", req)
+	}
+}
+
+func TestContextParamsMap(t *testing.T) {
+	var got map[string]string
+	r := New()
+	r.GET("/users/:user/repos/:repo/*path", func(c *Context) { got = c.ParamsMap() })
+	r.GET("/health", func(c *Context) { got = c.ParamsMap() })
+
+	performRequest(r, http.MethodGet, "/users/alice/repos/web/blob/main/README.md")
+	want := map[string]string{"user": "alice", "repo": "web", "path": "/blob/main/README.md"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParamsMap() = %v, want %v", got, want)
+	}
+
+	performRequest(r, http.MethodGet, "/health")
+	if got == nil || len(got) != 0 {
+		t.Errorf("ParamsMap() without params = %#v, want an empty map", got)
+	}
+
+	ps := Params{{Key: "id", Value: "1"}, {Key: "id", Value: "2"}}
+	if m := ps.Map(); m["id"] != "1" {
+		t.Errorf("Params.Map() duplicate key = %q, want the first value like Get", m["id"])
+	}
+}
+
+func TestContextXMLWithHeader(t *testing.T) {
+	type item struct {
+		ID int `xml:"id"`
+	}
+	for _, withHeader := range []bool{true, false} {
+		w := httptest.NewRecorder()
+		c := newTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		if withHeader {
+			c.XMLWithHeader(http.StatusOK, item{ID: 1})
+		} else {
+			c.XML(http.StatusOK, item{ID: 1})
+		}
+		if got := strings.HasPrefix(w.Body.String(), "<?xml "); got != withHeader {
+			t.Errorf("XMLWithHeader=%v: body = %q", withHeader, w.Body.String())
+		}
+	}
+}
+
+func TestContextBindJSONUseNumberRoundTrip(t *testing.T) {
+	defer func(old bool) { binding.EnableDecoderUseNumber = old }(binding.EnableDecoderUseNumber)
+	binding.EnableDecoderUseNumber = true
+
+	for name, render := range map[string]func(c *Context, obj interface{}){
+		"JSON":      func(c *Context, obj interface{}) { c.JSON(http.StatusOK, obj) },
+		"AsciiJSON": func(c *Context, obj interface{}) { c.AsciiJSON(http.StatusOK, obj) },
+	} {
+		w := httptest.NewRecorder()
+		c := newTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9007199254740993}`))
+		c.Request.Header.Set("Content-Type", MIMEJSON)
+
+		var obj map[string]interface{}
+		if err := c.ShouldBindJSON(&obj); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		render(c, obj)
+		if body := w.Body.String(); !strings.Contains(body, "9007199254740993") {
+			t.Errorf("%s: body = %q, want the id preserved exactly", name, body)
+		}
+	}
+}
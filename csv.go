@@ -0,0 +1,52 @@
+package web
+
+import (
+	"encoding/csv"
+
+	"github.com/lierbai/web/render"
+)
+
+// csvStreamFlushRows StreamCSV每写入多少行刷新一次
+const csvStreamFlushRows = 100
+
+// StreamCSV 以text/csv先写入header(为nil时不写),再流式写入rows中的每一行,直到rows关闭或客户端断开连接.
+// 与Stream相同,客户端在流结束前断开连接时返回true.写入失败的错误会附加到c.Errors并结束写入.
+func (c *Context) StreamCSV(code int, header []string, rows <-chan []string) bool {
+	render.CSV{}.WriteContentType(c.Writer)
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+
+	w := csv.NewWriter(c.Writer)
+	pending := 0
+	flush := func() {
+		pending = 0
+		w.Flush()
+		c.Writer.Flush()
+	}
+	defer flush()
+
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			c.Error(err) // nolint: errcheck
+			return false
+		}
+	}
+	clientGone := c.Writer.CloseNotify()
+	for {
+		select {
+		case <-clientGone:
+			return true
+		case row, ok := <-rows:
+			if !ok {
+				return false
+			}
+			if err := w.Write(row); err != nil {
+				c.Error(err) // nolint: errcheck
+				return c.Request.Context().Err() != nil
+			}
+			if pending++; pending >= csvStreamFlushRows {
+				flush()
+			}
+		}
+	}
+}
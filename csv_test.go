@@ -0,0 +1,92 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestContextStreamCSV(t *testing.T) {
+	var gone bool
+	r := New()
+	r.GET("/export", func(c *Context) {
+		rows := make(chan []string)
+		go func() {
+			defer close(rows)
+			for i := 1; i <= 250; i++ {
+				rows <- []string{strconv.Itoa(i), "row " + strconv.Itoa(i)}
+			}
+		}()
+		gone = c.StreamCSV(http.StatusOK, []string{"id", "name"}, rows)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("response = %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 251 || lines[0] != "id,name" || lines[1] != "1,row 1" || lines[250] != "250,row 250" {
+		t.Errorf("got %d lines, first %q, last %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+	if gone {
+		t.Error("StreamCSV reported a disconnect for a completed stream")
+	}
+}
+
+func TestContextStreamCSVClientDisconnect(t *testing.T) {
+	result := make(chan bool, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+	r := New()
+	r.GET("/export", func(c *Context) {
+		rows := make(chan []string)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case rows <- []string{strconv.Itoa(i)}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+		result <- c.StreamCSV(http.StatusOK, []string{"id"}, rows)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/export", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(resp.Body, make([]byte, 64)); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case gone := <-result:
+		if !gone {
+			t.Error("StreamCSV = false after the client disconnected, want true")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StreamCSV did not stop after the client disconnected")
+	}
+}
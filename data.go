@@ -43,3 +43,20 @@ func Err(code int, msg string, jsonObj interface{}) Data {
 	}
 	return data
 }
+
+// Pagination 分页列表响应中的分页信息.
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"pageSize"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
+
+// NewPagination 根据总数和每页大小计算总页数,pageSize<=0时总页数为0.
+func NewPagination(total, page, pageSize int) Pagination {
+	p := Pagination{Page: page, PageSize: pageSize, Total: total}
+	if pageSize > 0 {
+		p.TotalPages = (total + pageSize - 1) / pageSize
+	}
+	return p
+}
@@ -0,0 +1,32 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// WithDeadline 返回为c.Request附加d后超时的context的中间件,便于后续的数据库等调用在超时后被取消.
+// 它不会中止处理程序链,也不会写入响应,处理程序应自行检查c.Request.Context().Err().
+func WithDeadline(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// routeTimeout WithTimeout路由选项使用的处理程序:同WithDeadline,
+// 但处理程序链返回时已超时且尚未写入响应的,中止并返回503.
+func routeTimeout(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		if ctx.Err() == context.DeadlineExceeded && !c.Writer.Written() {
+			c.AbortWithStatus(http.StatusServiceUnavailable)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithDeadline(t *testing.T) {
+	const d = 50 * time.Millisecond
+	var (
+		deadline time.Time
+		hasDL    bool
+		ctx      context.Context
+	)
+	r := New()
+	r.Use(WithDeadline(d))
+	r.GET("/", func(c *Context) {
+		ctx = c.Request.Context()
+		deadline, hasDL = ctx.Deadline()
+	})
+
+	start := time.Now()
+	w := performRequest(r, http.MethodGet, "/")
+	if !hasDL {
+		t.Fatal("request context has no deadline")
+	}
+	if deadline.Before(start) || deadline.After(start.Add(d).Add(time.Second)) {
+		t.Errorf("deadline %v not within [%v, %v]", deadline, start, start.Add(d))
+	}
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("WithDeadline wrote a response: %d %q", w.Code, w.Body.String())
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Error("request context not cancelled after the chain returned")
+	}
+}
+
+func TestWithDeadlineExpires(t *testing.T) {
+	var err error
+	r := New()
+	r.Use(WithDeadline(10 * time.Millisecond))
+	r.GET("/", func(c *Context) {
+		<-c.Request.Context().Done()
+		err = c.Request.Context().Err()
+		c.Status(http.StatusAccepted)
+	})
+
+	w := performRequest(r, http.MethodGet, "/")
+	if err != context.DeadlineExceeded {
+		t.Errorf("ctx.Err() = %v, want DeadlineExceeded", err)
+	}
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want the handler's 202", w.Code)
+	}
+}
+
+func TestWithTimeoutRouteOption(t *testing.T) {
+	slow := func(c *Context) {
+		select {
+		case <-c.Request.Context().Done():
+		case <-time.After(5 * time.Second):
+			c.String(http.StatusOK, "done")
+		}
+	}
+	fast := func(c *Context) {
+		if _, ok := c.Request.Context().Deadline(); !ok {
+			t.Error("fast route: request context has no deadline")
+		}
+		c.String(http.StatusOK, "fast")
+	}
+	r := New()
+	r.HandleWithOptions(http.MethodGet, "/slow", []RouteOption{WithTimeout(20 * time.Millisecond)}, slow)
+	r.HandleWithOptions(http.MethodGet, "/fast", []RouteOption{WithTimeout(time.Second)}, fast)
+	r.GET("/plain", func(c *Context) {
+		if _, ok := c.Request.Context().Deadline(); ok {
+			t.Error("route without WithTimeout has a deadline")
+		}
+	})
+
+	begin := time.Now()
+	w := performRequest(r, http.MethodGet, "/slow")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /slow = %d, want 503", w.Code)
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("GET /slow took %v, want it cut short by the timeout", elapsed)
+	}
+
+	if w := performRequest(r, http.MethodGet, "/fast"); w.Code != http.StatusOK || w.Body.String() != "fast" {
+		t.Errorf("GET /fast = %d %q", w.Code, w.Body.String())
+	}
+	performRequest(r, http.MethodGet, "/plain")
+}
@@ -2,7 +2,9 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"strings"
 )
@@ -34,6 +36,23 @@ type Error struct {
 
 type errorMsgs []*Error
 
+type errorStatus struct {
+	target error
+	code   int
+}
+
+type errorStatuses []errorStatus
+
+// statusOf 返回第一个匹配错误的状态码,没有匹配返回500.
+func (es errorStatuses) statusOf(err error) int {
+	for _, e := range es {
+		if errors.Is(err, e.target) {
+			return e.code
+		}
+	}
+	return http.StatusInternalServerError
+}
+
 var _ error = &Error{}
 
 // SetType 设置错误的类型.
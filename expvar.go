@@ -0,0 +1,15 @@
+package web
+
+import "expvar"
+
+// DefaultExpvarPath RegisterExpvar默认的路由路径.
+const DefaultExpvarPath = "/debug/vars"
+
+// RegisterExpvar 在path(为空时使用DefaultExpvarPath)注册标准库expvar的JSON处理程序(包含memstats和cmdline).
+// 需要显式调用才会启用,生产环境中应通过middlewares(如BasicAuth)加以保护.
+func (centre *Centre) RegisterExpvar(path string, middlewares ...HandlerFunc) {
+	if path == "" {
+		path = DefaultExpvarPath
+	}
+	centre.Board("", middlewares...).GET(path, WrapH(expvar.Handler()))
+}
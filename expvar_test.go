@@ -0,0 +1,43 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterExpvar(t *testing.T) {
+	r := New()
+	if w := performRequest(r, http.MethodGet, DefaultExpvarPath); w.Code != http.StatusNotFound {
+		t.Errorf("GET %s before RegisterExpvar = %d, want 404", DefaultExpvarPath, w.Code)
+	}
+
+	r.RegisterExpvar("/metrics/vars")
+	w := performRequest(r, http.MethodGet, "/metrics/vars")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if ctype := w.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "application/json") {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Error("payload has no memstats")
+	}
+}
+
+func TestRegisterExpvarDefaultPathAndMiddleware(t *testing.T) {
+	r := New()
+	r.RegisterExpvar("", func(c *Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	})
+	if w := performRequest(r, http.MethodGet, DefaultExpvarPath); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET %s = %d, want 401 from the middleware", DefaultExpvarPath, w.Code)
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/lierbai/web
 
-go 1.14
+go 1.18
 
 require github.com/mattn/go-isatty v0.0.12 
@@ -0,0 +1,82 @@
+package web
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Host 返回只在请求的Host匹配pattern时执行路由的跳板,pattern支持"*.example.com"形式的通配子域名(不匹配example.com本身).
+// 每个pattern有独立的路由树,因此不同Host的跳板(以及centre本身)可以注册相同的路径.
+// 请求先按注册顺序在匹配的host路由树中查找,找不到时继续查找centre的路由(包括404/405等处理).
+// host路由树只做精确匹配(AutoHEAD同样适用),结尾'/'重定向等仍由centre的路由树处理.
+// api := router.Host("*.example.com")
+func (centre *Centre) Host(pattern string) IBoarder {
+	return &Boarder{
+		Handlers: centre.combineHandlers(nil),
+		basePath: centre.BasePath(),
+		centre:   centre,
+		host:     strings.ToLower(pattern),
+	}
+}
+
+// hostTrees 单个host pattern的路由树.
+type hostTrees struct {
+	pattern string
+	trees   methodTrees
+}
+
+// hostTrees 返回pattern的路由树,不存在时创建.
+func (centre *Centre) hostTrees(pattern string) *methodTrees {
+	for i := range centre.hosts {
+		if centre.hosts[i].pattern == pattern {
+			return &centre.hosts[i].trees
+		}
+	}
+	centre.hosts = append(centre.hosts, hostTrees{pattern: pattern})
+	return &centre.hosts[len(centre.hosts)-1].trees
+}
+
+// serveHost 在匹配请求Host的路由树中查找并执行路由,找到时返回true.
+func (centre *Centre) serveHost(c *Context, rPath string, escaped, unescape bool) bool {
+	host := requestHost(c.Request)
+	method := c.Request.Method
+	for _, h := range centre.hosts {
+		if !matchHost(h.pattern, host) {
+			continue
+		}
+		methods := []string{method}
+		if method == http.MethodHead && centre.AutoHEAD {
+			methods = append(methods, http.MethodGet)
+		}
+		for _, m := range methods {
+			root := h.trees.get(m)
+			if root == nil {
+				continue
+			}
+			if value := root.getValue(rPath, c.Params, escaped, unescape); value.handlers != nil {
+				serveRoute(c, value)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requestHost 返回请求的Host(小写,不含端口).
+func requestHost(req *http.Request) string {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// matchHost 判断host是否匹配pattern,"*."开头的pattern匹配任意子域名.
+func matchHost(pattern, host string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return len(host) > len(suffix) && strings.HasSuffix(host, suffix)
+	}
+	return host == pattern
+}
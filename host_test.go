@@ -0,0 +1,98 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func hostRequest(r http.Handler, method, host, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Host = host
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHostRouting(t *testing.T) {
+	r := New()
+	r.AutoHEAD = true
+	tenants := r.Host("*.example.com")
+	tenants.GET("/", func(c *Context) { c.String(http.StatusOK, "tenant") })
+	tenants.Board("/api").GET("/users", func(c *Context) { c.String(http.StatusOK, "tenant users") })
+	r.Host("admin.example.org").GET("/", func(c *Context) { c.String(http.StatusOK, "admin") })
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "main") })
+	r.GET("/health", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	tests := []struct {
+		method, host, path string
+		code               int
+		body               string
+	}{
+		{http.MethodGet, "acme.example.com", "/", http.StatusOK, "tenant"},
+		{http.MethodGet, "ACME.Example.com:8080", "/api/users", http.StatusOK, "tenant users"},
+		{http.MethodGet, "admin.example.org", "/", http.StatusOK, "admin"},
+		// 不匹配的host继续查找centre的路由
+		{http.MethodGet, "example.com", "/", http.StatusOK, "main"},
+		{http.MethodGet, "other.test", "/", http.StatusOK, "main"},
+		// host路由树中没有的路径也继续查找centre的路由
+		{http.MethodGet, "acme.example.com", "/health", http.StatusOK, "ok"},
+		{http.MethodGet, "other.test", "/api/users", http.StatusNotFound, "404 page not found"},
+		{http.MethodHead, "acme.example.com", "/api/users", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w := hostRequest(r, tt.method, tt.host, tt.path)
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Errorf("%s %s%s = %d %q, want %d %q", tt.method, tt.host, tt.path, w.Code, w.Body.String(), tt.code, tt.body)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern, host string
+		match         bool
+	}{
+		{"*.example.com", "a.example.com", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"example.com", "example.com", true},
+		{"example.com", "a.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(tt.pattern, tt.host); got != tt.match {
+			t.Errorf("matchHost(%q, %q) = %v", tt.pattern, tt.host, got)
+		}
+	}
+}
+
+func TestRemoveRouteHost(t *testing.T) {
+	r := New()
+	api := r.Host("api.example.com")
+	api.GET("/status", func(c *Context) { c.String(http.StatusOK, "api") })
+	api.GET("/version", func(c *Context) { c.String(http.StatusOK, "v1") })
+	r.GET("/status", func(c *Context) { c.String(http.StatusOK, "default") })
+
+	if !r.RemoveRoute(http.MethodGet, "/version") {
+		t.Fatal("RemoveRoute(GET /version) on a host route = false")
+	}
+	if w := hostRequest(r, http.MethodGet, "api.example.com", "/version"); w.Code != http.StatusNotFound {
+		t.Errorf("host GET /version after removal = %d, want 404", w.Code)
+	}
+	if w := hostRequest(r, http.MethodGet, "api.example.com", "/status"); w.Body.String() != "api" {
+		t.Errorf("host GET /status = %q, want api", w.Body.String())
+	}
+
+	if !r.RemoveRoute(http.MethodGet, "/status") {
+		t.Fatal("RemoveRoute(GET /status) = false")
+	}
+	for _, host := range []string{"api.example.com", "other.example.com"} {
+		if w := hostRequest(r, http.MethodGet, host, "/status"); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s/status after removal = %d, want 404", host, w.Code)
+		}
+	}
+	if routes := r.Routes(); len(routes) != 0 {
+		t.Errorf("Routes() = %v, want none", routes)
+	}
+}
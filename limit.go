@@ -0,0 +1,81 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// LimitRequest 返回限制请求URL长度和headers总大小的中间件.
+// URL超过maxURLLen时返回414,headers超过maxHeaderBytes时返回431.值<=0表示不限制.
+// 它是http.Server.MaxHeaderBytes的补充,用于无法控制服务器配置的场景.
+func LimitRequest(maxHeaderBytes, maxURLLen int) HandlerFunc {
+	return func(c *Context) {
+		if maxURLLen > 0 && len(requestURI(c.Request)) > maxURLLen {
+			c.AbortWithStatus(http.StatusRequestURITooLong)
+			return
+		}
+		if maxHeaderBytes > 0 && headerSize(c.Request) > maxHeaderBytes {
+			c.AbortWithStatus(http.StatusRequestHeaderFieldsTooLarge)
+			return
+		}
+	}
+}
+
+// requestURI 返回请求行中的原始URI,客户端构造的请求没有RequestURI时使用URL生成.
+func requestURI(req *http.Request) string {
+	if req.RequestURI != "" {
+		return req.RequestURI
+	}
+	return req.URL.RequestURI()
+}
+
+// headerSize 按"Key: Value\r\n"的格式计算headers的总字节数(包含Host).
+func headerSize(req *http.Request) int {
+	size := 0
+	if req.Host != "" {
+		size += len("Host: \r\n") + len(req.Host)
+	}
+	for key, values := range req.Header {
+		for _, value := range values {
+			size += len(key) + len(value) + len(": \r\n")
+		}
+	}
+	return size
+}
+
+// RequireContentType 返回限制请求Content-Type的中间件.
+// 对POST|PUT|PATCH请求,Content-Type不在types中时返回415并中止;其他方法直接通过.
+func RequireContentType(types ...string) HandlerFunc {
+	return func(c *Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			return
+		}
+		if !containsString(types, c.ContentType()) {
+			c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		}
+	}
+}
+
+// AllowedQueryParams 返回限制查询参数的中间件,出现keys以外的查询参数时返回400并中止.
+// 带方括号的键(如f[a]或f[0])按方括号前的名称匹配.
+func AllowedQueryParams(keys ...string) HandlerFunc {
+	allowed := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		allowed[key] = struct{}{}
+	}
+	return func(c *Context) {
+		c.getQueryCache()
+		for key := range c.queryCache {
+			if i := strings.IndexByte(key, '['); i > 0 {
+				key = key[:i]
+			}
+			if _, ok := allowed[key]; !ok {
+				c.AbortWithError(http.StatusBadRequest, fmt.Errorf("不允许的查询参数'%s'", key)) // nolint: errcheck
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitRequest(t *testing.T) {
+	r := New()
+	r.Use(LimitRequest(256, 32))
+	r.GET("/*path", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	if w := performRequest(r, http.MethodGet, "/short"); w.Code != http.StatusOK {
+		t.Errorf("short URL status = %d", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/"+strings.Repeat("a", 40)); w.Code != http.StatusRequestURITooLong {
+		t.Errorf("long URL status = %d, want 414", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/q?x="+strings.Repeat("b", 30)); w.Code != http.StatusRequestURITooLong {
+		t.Errorf("long query status = %d, want 414", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/short", nil)
+	req.Header.Set("X-Big", strings.Repeat("c", 300))
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("oversized headers status = %d, want 431", w.Code)
+	}
+}
+
+func TestRequireContentType(t *testing.T) {
+	r := New()
+	r.Use(RequireContentType(MIMEJSON))
+	ok := func(c *Context) { c.Status(http.StatusNoContent) }
+	r.GET("/items", ok)
+	r.POST("/items", ok)
+	r.PUT("/items", ok)
+
+	tests := []struct {
+		method, contentType string
+		code                int
+	}{
+		{http.MethodPost, "application/json; charset=utf-8", http.StatusNoContent},
+		{http.MethodPut, MIMEJSON, http.StatusNoContent},
+		{http.MethodPost, MIMEPOSTForm, http.StatusUnsupportedMediaType},
+		{http.MethodPut, "", http.StatusUnsupportedMediaType},
+		{http.MethodGet, "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, "/items", strings.NewReader("{}"))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		r.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("%s Content-Type %q = %d, want %d", tt.method, tt.contentType, w.Code, tt.code)
+		}
+	}
+}
+
+func TestAllowedQueryParams(t *testing.T) {
+	r := New()
+	r.Use(AllowedQueryParams("page", "f"))
+	r.GET("/items", func(c *Context) { c.Status(http.StatusNoContent) })
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusNoContent},
+		{"page=1&page=2", http.StatusNoContent},
+		{"f[a]=1&f[0]=2", http.StatusNoContent},
+		{"page=1&debug=1", http.StatusBadRequest},
+		{"g[a]=1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := performRequest(r, http.MethodGet, "/items?"+tt.query); w.Code != tt.code {
+			t.Errorf("?%s = %d, want %d", tt.query, w.Code, tt.code)
+		}
+	}
+}
@@ -31,11 +31,23 @@ const (
 
 var consoleColorMode = autoColor
 
+// ColorMode 单个日志中间件的颜色输出方式.
+type ColorMode int
+
+const (
+	ColorModeDefault ColorMode = iota // 跟随全局设置(ForceConsoleColor|DisableConsoleColor)
+	ColorModeAuto                     // 输出到终端时着色,忽略全局设置
+	ColorModeForce                    // 总是着色
+	ColorModeDisable                  // 从不着色
+)
+
 // LoggerConfig 定义日志中间件配置.
 type LoggerConfig struct {
 	Formatter LogFormatter // 可选格式器.有默认值
 	Output    io.Writer    // 可选写入器.有默认值
 	SkipPaths []string     // 可选.跳过写入的url路径(数组)
+	Millis    bool         // 可选.默认格式器以毫秒(%.3fms)输出耗时
+	ColorMode ColorMode    // 可选.颜色输出方式,默认跟随全局设置
 }
 
 // LogFormatter 给定格式器函数的签名传递给LoggerWithFormatter.
@@ -51,7 +63,10 @@ type LogFormatterParams struct {
 	Method       string                 // 来自客户端请求的方法
 	Path         string                 // 来自客户端请求的路径
 	ErrorMessage string                 // 处理请求时记录错误信息
+	AbortReason  string                 // c.AbortWithReason()设置的中止原因
 	isTerm       bool                   // 输出描述符是否指向终端
+	millis       bool                   // 是否以毫秒输出耗时
+	colorMode    ColorMode              // 颜色输出方式
 	BodySize     int                    // 响应体正文大小
 	Keys         map[string]interface{} // 请求的上下文中设置的键
 }
@@ -94,6 +109,11 @@ func (p *LogFormatterParams) MethodColor() string {
 	}
 }
 
+// LatencyMillis 返回以毫秒为单位的处理请求耗时.
+func (p *LogFormatterParams) LatencyMillis() float64 {
+	return float64(p.Latency) / float64(time.Millisecond)
+}
+
 // ResetColor 重置所有转义属性.
 func (p *LogFormatterParams) ResetColor() string {
 	return reset
@@ -101,6 +121,14 @@ func (p *LogFormatterParams) ResetColor() string {
 
 // IsOutputColor 是否可以将颜色输出到日志.
 func (p *LogFormatterParams) IsOutputColor() bool {
+	switch p.colorMode {
+	case ColorModeAuto:
+		return p.isTerm
+	case ColorModeForce:
+		return true
+	case ColorModeDisable:
+		return false
+	}
 	return consoleColorMode == forceColor || (consoleColorMode == autoColor && p.isTerm)
 }
 
@@ -112,17 +140,24 @@ var defaultLogFormatter = func(param LogFormatterParams) string {
 		methodColor = param.MethodColor()
 		resetColor = param.ResetColor()
 	}
-	if param.Latency > time.Minute {
+	var latency interface{} = param.Latency
+	if param.millis {
+		latency = fmt.Sprintf("%.3fms", param.LatencyMillis())
+	} else if param.Latency > time.Minute {
 		// Truncate in a golang < 1.8 safe way
-		param.Latency = param.Latency - param.Latency%time.Second
+		latency = param.Latency - param.Latency%time.Second
+	}
+	var abortReason string
+	if param.AbortReason != "" {
+		abortReason = " | " + param.AbortReason
 	}
-	return fmt.Sprintf("%v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+	return fmt.Sprintf("%v |%s %3d %s| %13v | %15s |%s %-7s %s %#v%s\n%s",
 		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
 		statusColor, param.StatusCode, resetColor,
-		param.Latency,
+		latency,
 		param.ClientIP,
 		methodColor, param.Method, resetColor,
-		param.Path,
+		param.Path, abortReason,
 		param.ErrorMessage,
 	)
 }
@@ -200,9 +235,11 @@ func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
 		// 仅当不跳过路径时才记录
 		if _, ok := skip[path]; !ok {
 			param := LogFormatterParams{
-				Request: c.Request,
-				isTerm:  isTerm,
-				Keys:    c.Keys,
+				Request:   c.Request,
+				isTerm:    isTerm,
+				millis:    conf.Millis,
+				colorMode: conf.ColorMode,
+				Keys:      c.Keys,
 			}
 
 			// 结束定时器
@@ -213,6 +250,7 @@ func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
 			param.Method = c.Request.Method
 			param.StatusCode = c.Writer.Status()
 			param.ErrorMessage = c.Errors.ByType(ErrorTypePrivate).String()
+			param.AbortReason = c.GetString(AbortReasonKey)
 
 			param.BodySize = c.Writer.Size()
 
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogFormatterParamsLatencyMillis(t *testing.T) {
+	p := LogFormatterParams{Latency: 1500 * time.Microsecond}
+	if got := p.LatencyMillis(); got != 1.5 {
+		t.Errorf("LatencyMillis() = %v, want 1.5", got)
+	}
+
+	p.TimeStamp = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	p.StatusCode, p.Method, p.Path = http.StatusOK, http.MethodGet, "/"
+	if out := defaultLogFormatter(p); !strings.Contains(out, "1.5ms |") {
+		t.Errorf("default format = %q, want Go-style 1.5ms", out)
+	}
+	p.millis = true
+	if out := defaultLogFormatter(p); !strings.Contains(out, "1.500ms |") {
+		t.Errorf("millis format = %q, want 1.500ms", out)
+	}
+}
+
+func TestLoggerMillisConfig(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{Output: &buf, Millis: true}))
+	r.GET("/", func(c *Context) {})
+	performRequest(r, http.MethodGet, "/")
+	if !strings.Contains(buf.String(), "ms |") || strings.Contains(buf.String(), "µs") {
+		t.Errorf("log line = %q, want millisecond latency", buf.String())
+	}
+}
+
+func TestLoggerAbortReason(t *testing.T) {
+	var reasons []string
+	r := New()
+	r.Use(LoggerWithFormatter(func(p LogFormatterParams) string {
+		reasons = append(reasons, p.AbortReason)
+		return ""
+	}))
+	r.GET("/denied", func(c *Context) { c.AbortWithReason(http.StatusTooManyRequests, "rate limited") })
+	r.GET("/ok", func(c *Context) {})
+
+	w := performRequest(r, http.MethodGet, "/denied")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", w.Code)
+	}
+	performRequest(r, http.MethodGet, "/ok")
+	if len(reasons) != 2 || reasons[0] != "rate limited" || reasons[1] != "" {
+		t.Errorf("logged reasons = %q", reasons)
+	}
+
+	var buf bytes.Buffer
+	r = New()
+	r.Use(LoggerWithWriter(&buf))
+	r.GET("/denied", func(c *Context) { c.AbortWithReason(http.StatusForbidden, "missing scope") })
+	performRequest(r, http.MethodGet, "/denied")
+	if !strings.Contains(buf.String(), "| missing scope") {
+		t.Errorf("default log line = %q, want the abort reason", buf.String())
+	}
+}
+
+func TestLoggerColorModePerInstance(t *testing.T) {
+	defer func() { consoleColorMode = autoColor }()
+	ForceConsoleColor()
+
+	outputs := map[ColorMode]*bytes.Buffer{}
+	r := New()
+	for _, mode := range []ColorMode{ColorModeDefault, ColorModeAuto, ColorModeForce, ColorModeDisable} {
+		outputs[mode] = new(bytes.Buffer)
+		r.Use(LoggerWithConfig(LoggerConfig{Output: outputs[mode], ColorMode: mode}))
+	}
+	r.GET("/", func(c *Context) { c.Status(http.StatusOK) })
+	performRequest(r, http.MethodGet, "/")
+
+	// 输出到bytes.Buffer(不是终端):只有强制着色和跟随全局的ForceConsoleColor输出颜色
+	want := map[ColorMode]bool{
+		ColorModeDefault: true,
+		ColorModeAuto:    false,
+		ColorModeForce:   true,
+		ColorModeDisable: false,
+	}
+	for mode, colored := range want {
+		out := outputs[mode].String()
+		if out == "" {
+			t.Errorf("mode %d: no log output", mode)
+		}
+		if got := strings.Contains(out, "\033["); got != colored {
+			t.Errorf("mode %d: colored = %v, want %v: %q", mode, got, colored, out)
+		}
+	}
+
+	DisableConsoleColor()
+	p := LogFormatterParams{colorMode: ColorModeForce}
+	if !p.IsOutputColor() {
+		t.Error("ColorModeForce with DisableConsoleColor: IsOutputColor() = false, want true")
+	}
+	p.colorMode = ColorModeDefault
+	if p.IsOutputColor() {
+		t.Error("ColorModeDefault with DisableConsoleColor: IsOutputColor() = true, want false")
+	}
+}
@@ -0,0 +1,70 @@
+package web
+
+import "encoding/json"
+
+// MIMENDJSON 换行分隔的JSON(newline-delimited JSON)数据格式.
+const MIMENDJSON = "application/x-ndjson"
+
+// ndjsonFlushEvery 每写入多少个对象刷新一次
+const ndjsonFlushEvery = 32
+
+// NDJSONWriter 每行写入一个JSON对象,用于流式输出批量结果.
+type NDJSONWriter struct {
+	c       *Context
+	encoder *json.Encoder
+	pending int
+}
+
+// NDJSON 写入状态码和Content-Type(application/x-ndjson),并返回逐行写入JSON对象的NDJSONWriter.
+func (c *Context) NDJSON(code int) *NDJSONWriter {
+	c.Header("Content-Type", MIMENDJSON)
+	c.Status(code)
+	c.Writer.WriteHeaderNow()
+	return &NDJSONWriter{c: c, encoder: json.NewEncoder(c.Writer)}
+}
+
+// Write 将obj序列化为一行JSON并写入,每写入一定数量后刷新.
+// 客户端断开连接后返回请求上下文的错误,不再写入.
+func (w *NDJSONWriter) Write(obj interface{}) error {
+	ctx := w.c.Request.Context()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	if err := w.encoder.Encode(obj); err != nil {
+		return err
+	}
+	if w.pending++; w.pending >= ndjsonFlushEvery {
+		w.Flush()
+	}
+	return nil
+}
+
+// Flush 立即将已写入的数据发送给客户端.
+func (w *NDJSONWriter) Flush() {
+	w.pending = 0
+	w.c.Writer.Flush()
+}
+
+// StreamJSON 以NDJSON格式流式写入items中的每个对象,直到items关闭或客户端断开连接.
+// 与Stream相同,客户端在流结束前断开连接时返回true.序列化失败的错误会附加到c.Errors并结束写入.
+func (c *Context) StreamJSON(code int, items <-chan interface{}) bool {
+	w := c.NDJSON(code)
+	defer w.Flush()
+	clientGone := c.Writer.CloseNotify()
+	for {
+		select {
+		case <-clientGone:
+			return true
+		case item, ok := <-items:
+			if !ok {
+				return false
+			}
+			if err := w.Write(item); err != nil {
+				c.Error(err) // nolint: errcheck
+				return c.Request.Context().Err() != nil
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type ndjsonItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestContextNDJSON(t *testing.T) {
+	r := New()
+	r.GET("/export", func(c *Context) {
+		w := c.NDJSON(http.StatusOK)
+		for i := 1; i <= 40; i++ {
+			if err := w.Write(ndjsonItem{ID: i, Name: "item"}); err != nil {
+				t.Errorf("Write(%d) error: %v", i, err)
+			}
+		}
+	})
+
+	w := performRequest(r, http.MethodGet, "/export")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != MIMENDJSON {
+		t.Fatalf("response = %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	n := 0
+	for scanner.Scan() {
+		n++
+		var item ndjsonItem
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil || item.ID != n {
+			t.Errorf("line %d = %q (%v)", n, scanner.Text(), err)
+		}
+	}
+	if n != 40 {
+		t.Errorf("got %d lines, want 40", n)
+	}
+}
+
+func TestNDJSONWriterStopsAfterDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := newTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	w := c.NDJSON(http.StatusOK)
+	if err := w.Write(ndjsonItem{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.Write(ndjsonItem{ID: 2}); err != context.Canceled {
+		t.Errorf("Write after disconnect error = %v, want context.Canceled", err)
+	}
+}
+
+func TestContextStreamJSON(t *testing.T) {
+	r := New()
+	var gone bool
+	r.GET("/stream", func(c *Context) {
+		items := make(chan interface{})
+		go func() {
+			defer close(items)
+			for i := 1; i <= 5; i++ {
+				items <- ndjsonItem{ID: i, Name: "streamed"}
+			}
+		}()
+		gone = c.StreamJSON(http.StatusOK, items)
+	})
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != MIMENDJSON {
+		t.Errorf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	decoder := json.NewDecoder(resp.Body)
+	n := 0
+	for decoder.More() {
+		var item ndjsonItem
+		if err := decoder.Decode(&item); err != nil {
+			t.Fatalf("decode line %d: %v", n+1, err)
+		}
+		n++
+		if item.ID != n {
+			t.Errorf("line %d id = %d", n, item.ID)
+		}
+	}
+	if n != 5 || gone {
+		t.Errorf("got %d items, clientGone = %v", n, gone)
+	}
+}
@@ -15,6 +15,15 @@ const (
 	catchAll
 )
 
+// unescapeMode 路由参数值的转义方式.
+type unescapeMode uint8
+
+const (
+	unescapeDefault unescapeMode = iota // 跟随全局UnescapePathValues
+	unescapeAlways                      // 总是转义
+	unescapeNever                       // 总是保留原始值
+)
+
 type node struct {
 	path      string
 	indices   string
@@ -25,6 +34,17 @@ type node struct {
 	maxParams uint8
 	wildChild bool
 	fullPath  string
+	unescape  unescapeMode
+}
+
+// clone 深度复制以n为根的树(处理程序链共享),用于不修改原树的试注册.
+func (n *node) clone() *node {
+	cp := *n
+	cp.children = make([]*node, len(n.children))
+	for i, child := range n.children {
+		cp.children[i] = child.clone()
+	}
+	return &cp
 }
 
 // increments 给定子节点的优先级,必要时重新排序.
@@ -50,17 +70,17 @@ func (n *node) incrementChildPrio(pos int) int {
 	return newPos
 }
 
-// addRoute 将具有给定句柄的节点添加到指定路径(非并发安全).
-func (n *node) addRoute(path string, handlers HandlersChain) {
+// addRoute 将具有给定句柄的节点添加到指定路径(非并发安全).返回保存句柄的叶子节点.
+func (n *node) addRoute(path string, handlers HandlersChain) *node {
 	fullPath := path
 	n.priority++
 	numParams := countParams(path)
 
 	// 如果树为空
 	if len(n.path) == 0 && len(n.children) == 0 {
-		n.insertChild(numParams, path, fullPath, handlers)
+		leaf := n.insertChild(numParams, path, fullPath, handlers)
 		n.nType = root
-		return
+		return leaf
 	}
 
 	parentFullPathIndex := 0
@@ -85,6 +105,7 @@ walk:
 				handlers:  n.handlers,
 				priority:  n.priority - 1,
 				fullPath:  n.fullPath,
+				unescape:  n.unescape,
 			}
 
 			// 更新maxparms (所有子级的最大值)
@@ -99,6 +120,7 @@ walk:
 			n.indices = string([]byte{n.path[i]})
 			n.path = path[:i]
 			n.handlers = nil
+			n.unescape = unescapeDefault
 			n.wildChild = false
 			n.fullPath = fullPath[:parentFullPathIndex+i]
 		}
@@ -166,8 +188,7 @@ walk:
 				n.incrementChildPrio(len(n.indices) - 1)
 				n = child
 			}
-			n.insertChild(numParams, path, fullPath, handlers)
-			return
+			return n.insertChild(numParams, path, fullPath, handlers)
 		}
 
 		// 否则 当前节点的handle
@@ -175,11 +196,11 @@ walk:
 			panic("已为路径 '" + fullPath + "'注册处理程序")
 		}
 		n.handlers = handlers
-		return
+		return n
 	}
 }
 
-func (n *node) insertChild(numParams uint8, path string, fullPath string, handlers HandlersChain) {
+func (n *node) insertChild(numParams uint8, path string, fullPath string, handlers HandlersChain) *node {
 	for numParams > 0 {
 		// 查找前缀直到第一个通配符
 		wildcard, i, valid := findWildcard(path)
@@ -236,7 +257,7 @@ func (n *node) insertChild(numParams uint8, path string, fullPath string, handle
 			}
 			// 否则我们结束了。将handle插入新叶子节点
 			n.handlers = handlers
-			return
+			return n
 		}
 
 		// 捕获所有
@@ -283,13 +304,14 @@ func (n *node) insertChild(numParams uint8, path string, fullPath string, handle
 		}
 		n.children = []*node{child}
 
-		return
+		return child
 	}
 
 	// 如果没有找到通配符，只需插入路径和句柄
 	n.path = path
 	n.handlers = handlers
 	n.fullPath = fullPath
+	return n
 }
 
 // nodeValue 用来保存 (*Node).getValue 方法的返回值
@@ -302,7 +324,8 @@ type nodeValue struct {
 
 // getValue 返回注册到给定路径 (key)的句柄. 通配符的值将保存到映射中.
 // 如果找不到句柄,存在没有结尾斜杠的句柄.提出TSR(尾部斜杠重定向)建议
-func (n *node) getValue(path string, po Params, unescape bool) (value nodeValue) {
+// escaped 表示path为未解码的原始路径,unescape为全局的转义设置(可被路由的转义选项覆盖).
+func (n *node) getValue(path string, po Params, escaped, unescape bool) (value nodeValue) {
 	value.params = po
 walk: // 在树上运行的外循环
 	for {
@@ -311,6 +334,7 @@ walk: // 在树上运行的外循环
 			// 我们应该到达了包含句柄的节点.检查此节点是否注册了句柄.
 			if value.handlers = n.handlers; value.handlers != nil {
 				value.fullPath = n.fullPath
+				value.unescapeParams(n.unescape, escaped, unescape)
 				return
 			}
 
@@ -369,15 +393,7 @@ walk: // 在树上运行的外循环
 				i := len(value.params)
 				value.params = value.params[:i+1] // 在预先分配的容量内扩展切片
 				value.params[i].Key = n.path[1:]
-				val := path[:end]
-				if unescape {
-					var err error
-					if value.params[i].Value, err = url.QueryUnescape(val); err != nil {
-						value.params[i].Value = val // 回退,在错误的情况下
-					}
-				} else {
-					value.params[i].Value = val
-				}
+				value.params[i].Value = path[:end]
 
 				// 我们需要更深入树
 				if end < len(path) {
@@ -394,6 +410,7 @@ walk: // 在树上运行的外循环
 
 				if value.handlers = n.handlers; value.handlers != nil {
 					value.fullPath = n.fullPath
+					value.unescapeParams(n.unescape, escaped, unescape)
 					return
 				}
 				if len(n.children) == 1 {
@@ -411,17 +428,11 @@ walk: // 在树上运行的外循环
 				i := len(value.params)
 				value.params = value.params[:i+1] // 在预先分配的容量内扩展切片
 				value.params[i].Key = n.path[2:]
-				if unescape {
-					var err error
-					if value.params[i].Value, err = url.QueryUnescape(path); err != nil {
-						value.params[i].Value = path // 回退,在错误的情况下
-					}
-				} else {
-					value.params[i].Value = path
-				}
+				value.params[i].Value = path
 
 				value.handlers = n.handlers
 				value.fullPath = n.fullPath
+				value.unescapeParams(n.unescape, escaped, unescape)
 				return
 
 			default:
@@ -437,6 +448,24 @@ walk: // 在树上运行的外循环
 	}
 }
 
+// unescapeParams 按路由的转义方式对参数值进行转义.仅当路径为原始路径时生效.
+func (value *nodeValue) unescapeParams(mode unescapeMode, escaped, unescape bool) {
+	switch mode {
+	case unescapeAlways:
+		unescape = true
+	case unescapeNever:
+		unescape = false
+	}
+	if !escaped || !unescape {
+		return
+	}
+	for i := range value.params {
+		if val, err := url.QueryUnescape(value.params[i].Value); err == nil {
+			value.params[i].Value = val // 在错误的情况下保留原始值
+		}
+	}
+}
+
 // findCaseInsensitivePath 对路径进行查找(不区分大小写),并尝试查找处理程序.
 // 返回大小写更正的路径和bool(查找是否成功).它还可以选择性地修复尾部斜杠.
 func (n *node) findCaseInsensitivePath(path string, fixTrailingSlash bool) (ciPath []byte, found bool) {
@@ -24,3 +24,12 @@ func (ps Params) ByName(name string) (va string) {
 	va, _ = ps.Get(name)
 	return
 }
+
+// Map 返回键值映射,同名键取首个值(与Get一致).
+func (ps Params) Map() map[string]string {
+	m := make(map[string]string, len(ps))
+	for i := len(ps) - 1; i >= 0; i-- {
+		m[ps[i].Key] = ps[i].Value
+	}
+	return m
+}
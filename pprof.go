@@ -0,0 +1,27 @@
+package web
+
+import "net/http/pprof"
+
+// DefaultPprofPrefix RegisterPprof默认的路由前缀.
+const DefaultPprofPrefix = "/debug/pprof"
+
+// RegisterPprof 在prefix(为空时使用DefaultPprofPrefix)下为net/http/pprof注册显式路由:
+// 索引页"/",cmdline|profile|symbol|trace,以及运行时内置的allocs|block|goroutine|heap|mutex|threadcreate.
+// 路由树不允许通配段与静态段同级,因此不注册"/:name",自定义的profile请另行注册pprof.Handler(name).
+// 需要显式调用才会启用,生产环境中应通过middlewares(如BasicAuth)加以保护.
+// router.RegisterPprof("", web.BasicAuth(web.Accounts{"admin": "secret"}))
+func (centre *Centre) RegisterPprof(prefix string, middlewares ...HandlerFunc) {
+	if prefix == "" {
+		prefix = DefaultPprofPrefix
+	}
+	board := centre.Board(prefix, middlewares...)
+	board.GET("/", WrapF(pprof.Index))
+	board.GET("/cmdline", WrapF(pprof.Cmdline))
+	board.GET("/profile", WrapF(pprof.Profile))
+	board.GET("/symbol", WrapF(pprof.Symbol))
+	board.POST("/symbol", WrapF(pprof.Symbol))
+	board.GET("/trace", WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		board.GET("/"+name, WrapH(pprof.Handler(name)))
+	}
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	r := New()
+	r.RegisterPprof("/admin/pprof")
+
+	tests := []struct {
+		method, path string
+		code         int
+		contains     string
+	}{
+		{http.MethodGet, "/admin/pprof/", http.StatusOK, "goroutine"},
+		{http.MethodGet, "/admin/pprof/heap?debug=1", http.StatusOK, "heap profile"},
+		{http.MethodGet, "/admin/pprof/goroutine?debug=1", http.StatusOK, "goroutine profile"},
+		{http.MethodGet, "/admin/pprof/cmdline", http.StatusOK, ""},
+		{http.MethodPost, "/admin/pprof/symbol", http.StatusOK, "num_symbols"},
+		{http.MethodGet, "/admin/pprof/allocs?debug=1", http.StatusOK, "heap profile"},
+		{http.MethodGet, "/admin/pprof/nosuch", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, tt.method, tt.path)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s %s = %d, body contains %q: %v", tt.method, tt.path, w.Code, tt.contains, strings.Contains(w.Body.String(), tt.contains))
+		}
+	}
+}
+
+func TestRegisterPprofRoutes(t *testing.T) {
+	r := New()
+	r.RegisterPprof("")
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	for _, want := range []string{
+		"GET /debug/pprof/", "GET /debug/pprof/cmdline", "GET /debug/pprof/profile",
+		"GET /debug/pprof/symbol", "POST /debug/pprof/symbol", "GET /debug/pprof/trace",
+		"GET /debug/pprof/heap", "GET /debug/pprof/goroutine", "GET /debug/pprof/threadcreate",
+	} {
+		if !registered[want] {
+			t.Errorf("route %s not registered", want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// RequestIDHeader 传递请求ID的header名称.
+const RequestIDHeader = "X-Request-Id"
+
+// proxyInfoKey 在转发请求的context中保存proxyInfo的键.
+type proxyInfoKey struct{}
+
+// proxyInfo 处理程序为Director准备的单个请求的转发信息.
+type proxyInfo struct {
+	path      string // 去掉前缀后的路径
+	rawPath   string // path的转义形式(保留%2F等转义的段)
+	requestID string
+	hop       string // 追加到X-Forwarded-For的客户端地址
+}
+
+// ReverseProxy 返回将请求转发到target的处理程序(基于httputil.NewSingleHostReverseProxy).
+// 路由以通配参数结尾时(如"/api/*rest",参数名任意),转发路径为target的路径加上通配参数的值;
+// 否则去掉stripPrefix(可选,如跳板前缀"/api")后转发,未指定时原样转发请求路径.
+// 转发请求的X-Request-Id为请求的X-Request-Id(没有时使用已设置的响应header),
+// 直接连接的客户端地址追加到X-Forwarded-For.c.Request本身不会被修改.
+// router.Any("/api/*rest", web.ReverseProxy("http://127.0.0.1:9000"))
+func ReverseProxy(target string, stripPrefix ...string) HandlerFunc {
+	u, err := url.Parse(target)
+	if err != nil {
+		panic("反向代理的目标地址无效: " + err.Error())
+	}
+	prefix := ""
+	if len(stripPrefix) > 0 {
+		prefix = strings.TrimSuffix(stripPrefix[0], "/")
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		info, _ := req.Context().Value(proxyInfoKey{}).(*proxyInfo)
+		if info != nil {
+			req.URL.Path, req.URL.RawPath = info.path, info.rawPath
+		}
+		director(req)
+		if info == nil {
+			return
+		}
+		if info.requestID != "" && req.Header.Get(RequestIDHeader) == "" {
+			req.Header.Set(RequestIDHeader, info.requestID)
+		}
+		if info.hop != "" {
+			forwarded := info.hop
+			if prior := req.Header.Values("X-Forwarded-For"); len(prior) > 0 {
+				forwarded = strings.Join(prior, ", ") + ", " + info.hop
+			}
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+	}
+
+	return func(c *Context) {
+		info := &proxyInfo{requestID: c.requestHeader(RequestIDHeader)}
+		if info.requestID == "" {
+			info.requestID = c.Writer.Header().Get(RequestIDHeader)
+		}
+		if host, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr)); err == nil {
+			info.hop = host
+		}
+		info.path, info.rawPath = proxyPath(c, prefix)
+
+		req := c.Request.WithContext(context.WithValue(c.Request.Context(), proxyInfoKey{}, info))
+		// X-Forwarded-For由Director追加,清空RemoteAddr避免httputil再追加一次
+		req.RemoteAddr = ""
+		proxy.ServeHTTP(c.Writer, req)
+	}
+}
+
+// proxyPath 返回转发的路径及其转义形式.
+// 路由有通配参数时取通配参数对应的部分,否则去掉prefix;转义形式从c.Request.URL.EscapedPath()截取,
+// 因此%2F等转义的段会原样转发.
+func proxyPath(c *Context, prefix string) (string, string) {
+	escaped := c.Request.URL.EscapedPath()
+	skip := -1 // 去掉的前缀所包含的'/'个数
+	if star := strings.IndexByte(c.FullPath(), '*'); star >= 0 {
+		skip = strings.Count(c.FullPath()[:star], "/") - 1
+	} else if prefix != "" && (c.Request.URL.Path == prefix || strings.HasPrefix(c.Request.URL.Path, prefix+"/")) {
+		skip = strings.Count(prefix, "/")
+	}
+	if skip < 0 {
+		return c.Request.URL.Path, c.Request.URL.RawPath
+	}
+
+	rest := "/"
+	for i, n := 0, 0; i < len(escaped); i++ {
+		if escaped[i] != '/' {
+			continue
+		}
+		if n == skip {
+			rest = escaped[i:]
+			break
+		}
+		n++
+	}
+	path, err := url.PathUnescape(rest)
+	if err != nil {
+		return rest, ""
+	}
+	if path == rest {
+		return path, ""
+	}
+	return path, rest
+}
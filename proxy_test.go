@@ -0,0 +1,128 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// upstreamEcho 返回将收到的路径和header以JSON写回的上游服务器.
+func upstreamEcho(t *testing.T) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{ // nolint: errcheck
+			"path":      r.URL.Path,
+			"rawPath":   r.URL.RawPath,
+			"requestID": r.Header.Get(RequestIDHeader),
+			"forwarded": r.Header.Get("X-Forwarded-For"),
+		})
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// proxyGet 通过运行router的测试服务器(客户端地址为127.0.0.1)发起GET请求,并解码上游的回显.
+// 使用真实服务器是因为httputil.ReverseProxy需要CloseNotifier.
+func proxyGet(t *testing.T, r *Centre, path string, header http.Header) map[string]string {
+	t.Helper()
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s status = %d", path, resp.StatusCode)
+	}
+	var echo map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&echo); err != nil {
+		t.Fatalf("decode upstream echo: %v", err)
+	}
+	return echo
+}
+
+func TestReverseProxyForwardsRequestIDAndForwardedFor(t *testing.T) {
+	upstream := upstreamEcho(t)
+	r := New()
+	var incoming http.Header
+	r.GET("/api/*filepath", func(c *Context) {
+		incoming = c.Request.Header
+		c.Next()
+	}, ReverseProxy(upstream.URL))
+
+	echo := proxyGet(t, r, "/api/users", http.Header{
+		RequestIDHeader:   {"req-123"},
+		"X-Forwarded-For": {"198.51.100.1"},
+	})
+
+	if echo["requestID"] != "req-123" {
+		t.Errorf("upstream X-Request-Id = %q, want req-123", echo["requestID"])
+	}
+	if want := "198.51.100.1, 127.0.0.1"; echo["forwarded"] != want {
+		t.Errorf("upstream X-Forwarded-For = %q, want %q", echo["forwarded"], want)
+	}
+	if got := incoming.Get("X-Forwarded-For"); got != "198.51.100.1" {
+		t.Errorf("incoming X-Forwarded-For modified to %q", got)
+	}
+}
+
+func TestReverseProxyRequestIDFromResponseHeader(t *testing.T) {
+	upstream := upstreamEcho(t)
+	r := New()
+	var incoming http.Header
+	r.Use(func(c *Context) {
+		incoming = c.Request.Header
+		c.Header(RequestIDHeader, "generated-1")
+	})
+	r.GET("/api/*filepath", ReverseProxy(upstream.URL))
+
+	echo := proxyGet(t, r, "/api/x", nil)
+
+	if echo["requestID"] != "generated-1" {
+		t.Errorf("upstream X-Request-Id = %q, want generated-1", echo["requestID"])
+	}
+	if echo["forwarded"] != "127.0.0.1" {
+		t.Errorf("upstream X-Forwarded-For = %q, want 127.0.0.1", echo["forwarded"])
+	}
+	if got := incoming.Get(RequestIDHeader); got != "" {
+		t.Errorf("incoming X-Request-Id modified to %q", got)
+	}
+}
+
+func TestReverseProxyForwardedPath(t *testing.T) {
+	upstream := upstreamEcho(t)
+	tests := []struct {
+		route   string
+		proxy   HandlerFunc
+		request string
+		path    string
+		rawPath string
+	}{
+		{"/api/*filepath", ReverseProxy(upstream.URL), "/api/users/1", "/users/1", ""},
+		{"/api/*rest", ReverseProxy(upstream.URL), "/api/users/1", "/users/1", ""},
+		{"/api/*rest", ReverseProxy(upstream.URL), "/api/", "/", ""},
+		{"/t/:tenant/*rest", ReverseProxy(upstream.URL), "/t/acme/orders", "/orders", ""},
+		{"/api/*rest", ReverseProxy(upstream.URL), "/api/files/a%2Fb", "/files/a/b", "/files/a%2Fb"},
+		{"/svc/users", ReverseProxy(upstream.URL, "/svc/"), "/svc/users", "/users", ""},
+		{"/svc/users", ReverseProxy(upstream.URL), "/svc/users", "/svc/users", ""},
+	}
+	for _, tt := range tests {
+		r := New()
+		r.GET(tt.route, tt.proxy)
+		echo := proxyGet(t, r, tt.request, nil)
+		if echo["path"] != tt.path || echo["rawPath"] != tt.rawPath {
+			t.Errorf("%s %s: upstream path = %q raw %q, want %q raw %q",
+				tt.route, tt.request, echo["path"], echo["rawPath"], tt.path, tt.rawPath)
+		}
+	}
+}
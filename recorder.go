@@ -0,0 +1,62 @@
+package web
+
+import "bytes"
+
+// ResponseBodyKey ResponseRecorder捕获的响应体在上下文中的存储键.
+const ResponseBodyKey = "_lierbai/web/responsebodykey"
+
+// bodyRecorder 将写入的响应体同时复制到内存缓冲区,超过上限或刷新(流式响应)后停止捕获.
+type bodyRecorder struct {
+	ResponseWriter
+	buf      bytes.Buffer
+	limit    int
+	disabled bool
+}
+
+func (w *bodyRecorder) record(n int, write func()) {
+	if w.disabled {
+		return
+	}
+	if w.buf.Len()+n > w.limit {
+		w.disable()
+		return
+	}
+	write()
+}
+
+func (w *bodyRecorder) disable() {
+	w.disabled = true
+	w.buf = bytes.Buffer{}
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.record(len(data), func() { w.buf.Write(data) })
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.record(len(s), func() { w.buf.WriteString(s) })
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Flush 实现http.Flusher接口.刷新意味着流式响应,不再捕获.
+func (w *bodyRecorder) Flush() {
+	w.disable()
+	w.ResponseWriter.Flush()
+}
+
+// ResponseRecorder 返回捕获响应体的中间件(用于审计|调试),c.Next()后响应体以[]byte存储到上下文(ResponseBodyKey).
+// 响应体超过maxBytes或处理程序刷新了响应(流式输出)时不捕获,此时上下文中没有ResponseBodyKey.
+func ResponseRecorder(maxBytes int) HandlerFunc {
+	return func(c *Context) {
+		rec := &bodyRecorder{limit: maxBytes}
+		c.WrapWriter(func(w ResponseWriter) ResponseWriter {
+			rec.ResponseWriter = w
+			return rec
+		})
+		c.Next()
+		if !rec.disabled {
+			c.Set(ResponseBodyKey, rec.buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	captured := map[string][]byte{}
+	audit := func(c *Context) {
+		c.Next()
+		if body, ok := c.Get(ResponseBodyKey); ok {
+			captured[c.Request.URL.Path] = body.([]byte)
+		}
+	}
+	r := New()
+	r.Use(audit, ResponseRecorder(64))
+	r.GET("/json", func(c *Context) { c.JSON(http.StatusOK, Data{"id": 1, "name": "web"}) })
+	r.GET("/large", func(c *Context) { c.String(http.StatusOK, strings.Repeat("x", 65)) })
+	r.GET("/stream", func(c *Context) {
+		c.Writer.WriteString("chunk") // nolint: errcheck
+		c.Writer.Flush()
+	})
+
+	w := performRequest(r, http.MethodGet, "/json")
+	if got := string(captured["/json"]); got != `{"id":1,"name":"web"}` || got != w.Body.String() {
+		t.Errorf("captured /json = %q, response %q", got, w.Body.String())
+	}
+
+	if w := performRequest(r, http.MethodGet, "/large"); w.Body.Len() != 65 {
+		t.Errorf("/large response length = %d, want 65", w.Body.Len())
+	}
+	if body, ok := captured["/large"]; ok {
+		t.Errorf("captured /large = %d bytes, want nothing over the cap", len(body))
+	}
+
+	if w := performRequest(r, http.MethodGet, "/stream"); w.Body.String() != "chunk" {
+		t.Errorf("/stream response = %q", w.Body.String())
+	}
+	if body, ok := captured["/stream"]; ok {
+		t.Errorf("captured /stream = %q, want nothing after Flush", body)
+	}
+}
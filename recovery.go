@@ -80,6 +80,35 @@ func RecoveryWithWriter(out io.Writer) HandlerFunc {
 	}
 }
 
+// SafeHandler 封装非关键的中间件h(如统计分析):h中的panic会被恢复并输出到DefaultErrorWriter,然后继续执行后续处理程序.
+// h调用c.Next()之后发生的panic(来自后续处理程序)以及http.ErrAbortHandler会继续向上传递,交给Recovery处理.
+func SafeHandler(h HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		index := c.index
+		panicked := true
+		func() {
+			defer func() {
+				if !panicked {
+					return
+				}
+				rec := recover()
+				if rec == http.ErrAbortHandler || c.index != index {
+					panic(rec)
+				}
+				if DefaultErrorWriter != nil {
+					fmt.Fprintf(DefaultErrorWriter, "[SafeHandler] %s panic recovered in %s: %v\n%s",
+						timeFormat(time.Now()), nameOfFunction(h), rec, stack(3))
+				}
+			}()
+			h(c)
+			panicked = false
+		}()
+		if panicked {
+			c.Next()
+		}
+	}
+}
+
 // stack returns a nicely formatted stack frame, skipping skip frames.
 func stack(skip int) []byte {
 	buf := new(bytes.Buffer) // the returned data
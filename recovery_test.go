@@ -0,0 +1,43 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSafeHandlerContinuesChain(t *testing.T) {
+	var log bytes.Buffer
+	defer func(w io.Writer) { DefaultErrorWriter = w }(DefaultErrorWriter)
+	DefaultErrorWriter = &log
+
+	analytics := func(c *Context) {
+		panic("analytics backend down")
+	}
+	r := New()
+	r.Use(RecoveryWithWriter(io.Discard), SafeHandler(analytics))
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "ok") })
+
+	w := performRequest(r, http.MethodGet, "/")
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Errorf("GET / = %d %q, want 200 ok", w.Code, w.Body.String())
+	}
+	if !strings.Contains(log.String(), "analytics backend down") {
+		t.Errorf("error log = %q, want the recovered panic", log.String())
+	}
+}
+
+func TestSafeHandlerPropagatesDownstreamPanic(t *testing.T) {
+	defer func(w io.Writer) { DefaultErrorWriter = w }(DefaultErrorWriter)
+	DefaultErrorWriter = io.Discard
+
+	r := New()
+	r.Use(RecoveryWithWriter(io.Discard), SafeHandler(func(c *Context) { c.Next() }))
+	r.GET("/", func(c *Context) { panic("handler bug") })
+
+	if w := performRequest(r, http.MethodGet, "/"); w.Code != http.StatusInternalServerError {
+		t.Errorf("GET / = %d, want 500 from Recovery", w.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package render
+
+import (
+	"encoding/csv"
+	"net/http"
+)
+
+// csvFlushRows CSV每写入多少行刷新一次,大数据量时增量输出而不是全部缓冲.
+const csvFlushRows = 1000
+
+var csvContentType = []string{"text/csv; charset=utf-8"}
+
+// CSV 包含要写入的记录,Filename不为空时设置Content-Disposition为以该文件名下载.
+type CSV struct {
+	Records  [][]string
+	Filename string
+}
+
+// Render (CSV) 写入 ContentType 并逐行写入记录,每csvFlushRows行刷新一次.
+func (r CSV) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	if r.Filename != "" {
+		w.Header().Set("Content-Disposition", ContentDisposition("attachment", r.Filename))
+	}
+	writer := csv.NewWriter(w)
+	for i, record := range r.Records {
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if (i+1)%csvFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteContentType (CSV) 写入 CSV ContentType.
+func (r CSV) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, csvContentType)
+}
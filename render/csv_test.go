@@ -0,0 +1,65 @@
+package render
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCSVRender(t *testing.T) {
+	w := httptest.NewRecorder()
+	records := [][]string{
+		{"id", "name", "note"},
+		{"1", "web", "plain"},
+		{"2", "a,b", `say "hi"`},
+	}
+	if err := (CSV{Records: records}).Render(w); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name,note\n1,web,plain\n2,\"a,b\",\"say \"\"hi\"\"\"\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+	if ctype := w.Header().Get("Content-Type"); ctype != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ctype)
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "" {
+		t.Errorf("Content-Disposition = %q, want none without Filename", disposition)
+	}
+}
+
+func TestCSVRenderFilename(t *testing.T) {
+	tests := []struct {
+		filename, want string
+	}{
+		{"report.csv", `attachment; filename="report.csv"`},
+		{"报表.csv", `attachment; filename="__.csv"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.csv`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := (CSV{Records: [][]string{{"a"}}, Filename: tt.filename}).Render(w); err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Header().Get("Content-Disposition"); got != tt.want {
+			t.Errorf("Filename %q: Content-Disposition = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestCSVRenderFlushesIncrementally(t *testing.T) {
+	records := make([][]string, csvFlushRows+1)
+	for i := range records {
+		records[i] = []string{strconv.Itoa(i)}
+	}
+	w := httptest.NewRecorder()
+	if err := (CSV{Records: records}).Render(w); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Flushed {
+		t.Errorf("%d rows were written without flushing", len(records))
+	}
+	if n := strings.Count(w.Body.String(), "\n"); n != len(records) {
+		t.Errorf("got %d lines, want %d", n, len(records))
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import "strings"
+
+const upperHex = "0123456789ABCDEF"
+
+// ContentDisposition 生成Content-Disposition的值,kind为attachment或inline.
+// 文件名只包含可打印ASCII字符时只输出filename="...";否则按RFC 6266同时输出ASCII回退的filename="..."
+// (非ASCII|控制字符替换为'_')和RFC 5987编码的filename*(UTF-8),支持的客户端优先使用后者.
+func ContentDisposition(kind, filename string) string {
+	if isPrintableASCII(filename) {
+		return kind + "; filename=" + quoteFilename(filename)
+	}
+	return kind + "; filename=" + quoteFilename(asciiFallback(filename)) + "; filename*=UTF-8''" + encodeExtValue(filename)
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteFilename 以quoted-string输出s,转义'"'和'\'.
+func quoteFilename(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// asciiFallback 将非ASCII字符(按rune)和控制字符替换为'_'.
+func asciiFallback(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r < 0x20 || r >= 0x7f {
+			b.WriteByte('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// encodeExtValue 按RFC 5987对s进行百分号编码,attr-char以外的字节都会被编码.
+func encodeExtValue(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) * 3)
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; isAttrChar(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(upperHex[c>>4])
+			b.WriteByte(upperHex[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+// isAttrChar RFC 5987 attr-char: ALPHA / DIGIT / "!" / "#" / "$" / "&" / "+" / "-" / "." / "^" / "_" / "`" / "|" / "~"
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -1,28 +1,50 @@
 package render
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"io"
 	"net/http"
-
-	"github.com/lierbai/web/internal/bytesconv"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // JSON 包含给定的数据接口对象.
 type JSON struct {
 	Indented bool
+	Indent   string // Indented时每级的缩进,为空则使用defaultIndent
 	IsAscii  bool
 	IsPrue   bool
-	Data     interface{}
+	// ContentLength 为true时先完整序列化再写入,并设置Content-Length(避免分块传输)
+	ContentLength bool
+	Data          interface{}
 }
 
+// defaultIndent 默认的JSON缩进(四个空格)
+const defaultIndent = "    "
+
 var jsonContentType = []string{"application/json; charset=utf-8"}
 var jsonAsciiContentType = []string{"application/json"}
 
 // Render (JSON) 写入数据 和 ContentType
 func (r JSON) Render(w http.ResponseWriter) (err error) {
 	r.WriteContentType(w)
+	if !r.ContentLength {
+		return r.render(w)
+	}
+	var buf bytes.Buffer
+	if err = r.render(&buf); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// render 将序列化后的数据写入w.
+func (r JSON) render(w io.Writer) (err error) {
 	if r.IsPrue {
 		encoder := json.NewEncoder(w)
 		encoder.SetEscapeHTML(false)
@@ -33,7 +55,7 @@ func (r JSON) Render(w http.ResponseWriter) (err error) {
 		return err
 	}
 	if r.IsAscii {
-		jsonBytes = r.write(jsonBytes)
+		return r.write(w, jsonBytes)
 	}
 	_, err = w.Write(jsonBytes)
 	return err
@@ -54,18 +76,45 @@ func (r JSON) marshal() ([]byte, error) {
 	if !r.Indented {
 		return json.Marshal(r.Data)
 	}
-	return json.MarshalIndent(r.Data, "", "    ")
+	indent := r.Indent
+	if indent == "" {
+		indent = defaultIndent
+	}
+	return json.MarshalIndent(r.Data, "", indent)
 }
 
-// write 执行写入
-func (r JSON) write(jsonBytes []byte) []byte {
-	var buffer bytes.Buffer
-	for _, r := range bytesconv.BytesToString(jsonBytes) {
-		cvt := string(r)
-		if r >= 128 {
-			cvt = fmt.Sprintf("\\u%04x", int64(r))
+// write 将jsonBytes写入w,非ASCII字符转义为\uXXXX(BMP以外的字符转义为UTF-16代理对).
+// 通过缓冲写入器直接写入,ASCII片段原样写入,不额外复制整个响应体.
+// 数字(包括json.Number)只由ASCII字符组成,原样写入,不会损失精度.
+func (r JSON) write(w io.Writer, jsonBytes []byte) error {
+	bw := bufio.NewWriter(w)
+	start := 0
+	for i := 0; i < len(jsonBytes); {
+		if jsonBytes[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		bw.Write(jsonBytes[start:i]) // nolint: errcheck
+		c, size := utf8.DecodeRune(jsonBytes[i:])
+		if c > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(c)
+			writeUnicodeEscape(bw, r1)
+			writeUnicodeEscape(bw, r2)
+		} else {
+			writeUnicodeEscape(bw, c)
 		}
-		buffer.WriteString(cvt)
+		i += size
+		start = i
+	}
+	bw.Write(jsonBytes[start:]) // nolint: errcheck
+	return bw.Flush()
+}
+
+// writeUnicodeEscape 写入\uXXXX格式(小写十六进制)的转义字符.
+func writeUnicodeEscape(w *bufio.Writer, c rune) {
+	const hex = "0123456789abcdef"
+	w.WriteString(`\u`) // nolint: errcheck
+	for shift := 12; shift >= 0; shift -= 4 {
+		w.WriteByte(hex[c>>uint(shift)&0xF]) // nolint: errcheck
 	}
-	return buffer.Bytes()
 }
@@ -0,0 +1,130 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestAsciiJSONPreservesJSONNumber(t *testing.T) {
+	const big = "9007199254740993" // 2^53+1,float64无法精确表示
+	var data map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{"id":` + big + `,"name":"数"}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []JSON{{Data: data}, {Data: data, IsAscii: true}} {
+		w := httptest.NewRecorder()
+		if err := r.Render(w); err != nil {
+			t.Fatalf("IsAscii=%v Render error: %v", r.IsAscii, err)
+		}
+		if !strings.Contains(w.Body.String(), `"id":`+big) {
+			t.Errorf("IsAscii=%v body = %s, want id %s", r.IsAscii, w.Body.String(), big)
+		}
+	}
+}
+
+func TestAsciiJSONSurrogatePairs(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := JSON{Data: map[string]string{"msg": "hi 中😀"}, IsAscii: true}
+	if err := r.Render(w); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := w.Body.String(), `{"msg":"hi \u4e2d\ud83d\ude00"}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	var decoded map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil || decoded["msg"] != "hi 中😀" {
+		t.Errorf("round-trip = %q, %v", decoded["msg"], err)
+	}
+}
+
+func TestIndentedJSONIndent(t *testing.T) {
+	data := map[string]interface{}{"a": map[string]int{"b": 1}}
+	tests := []struct {
+		indent, want string
+	}{
+		{"", "{\n    \"a\": {\n        \"b\": 1\n    }\n}"},
+		{"\t", "{\n\t\"a\": {\n\t\t\"b\": 1\n\t}\n}"},
+		{"  ", "{\n  \"a\": {\n    \"b\": 1\n  }\n}"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := (JSON{Data: data, Indented: true, Indent: tt.indent}).Render(w); err != nil {
+			t.Fatal(err)
+		}
+		if w.Body.String() != tt.want {
+			t.Errorf("Indent %q: body = %q, want %q", tt.indent, w.Body.String(), tt.want)
+		}
+	}
+}
+
+// legacyAsciiJSON 逐rune写入bytes.Buffer的旧实现,用于对比输出.
+func legacyAsciiJSON(jsonBytes []byte) []byte {
+	var buffer bytes.Buffer
+	for _, r := range string(jsonBytes) {
+		switch {
+		case r < 128:
+			buffer.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buffer, "\\u%04x\\u%04x", r1, r2)
+		default:
+			fmt.Fprintf(&buffer, "\\u%04x", int64(r))
+		}
+	}
+	return buffer.Bytes()
+}
+
+func TestAsciiJSONMatchesLegacyOutput(t *testing.T) {
+	data := map[string]interface{}{
+		"en":    "hello, world",
+		"zh":    "你好,世界",
+		"ja":    "こんにちは",
+		"ru":    "Привет",
+		"ar":    "مرحبا",
+		"emoji": "👋🌍",
+		"mixed": []string{"café", "naïve", "ß", "€100"},
+	}
+	jsonBytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	if err := (JSON{Data: data, IsAscii: true}).Render(w); err != nil {
+		t.Fatal(err)
+	}
+	if want := legacyAsciiJSON(jsonBytes); !bytes.Equal(w.Body.Bytes(), want) {
+		t.Errorf("body = %s\nwant  %s", w.Body.Bytes(), want)
+	}
+}
+
+func BenchmarkAsciiJSON(b *testing.B) {
+	data := map[string]string{"text": strings.Repeat("héllo 世界 😀 ", 4096)}
+	r := JSON{Data: data, IsAscii: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := r.Render(discardWriter{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// discardWriter 丢弃写入内容的http.ResponseWriter.
+type discardWriter struct{}
+
+func (discardWriter) Header() http.Header         { return http.Header{} }
+func (discardWriter) Write(p []byte) (int, error) { return io.Discard.Write(p) }
+func (discardWriter) WriteHeader(int)             {}
@@ -18,6 +18,7 @@ var (
 	_ HTMLRender = HTMLDebug{}
 	_ HTMLRender = HTMLProduction{}
 	_ Render     = Reader{}
+	_ Render     = CSV{}
 )
 
 func writeContentType(w http.ResponseWriter, value []string) {
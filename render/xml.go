@@ -2,12 +2,14 @@ package render
 
 import (
 	"encoding/xml"
+	"io"
 	"net/http"
 )
 
 // XML 包含给定的接口对象.
 type XML struct {
-	Data interface{}
+	Data   interface{}
+	Header bool // 为true时在数据前写入<?xml version="1.0" encoding="UTF-8"?>声明
 }
 
 var xmlContentType = []string{"application/xml; charset=utf-8"}
@@ -15,6 +17,11 @@ var xmlContentType = []string{"application/xml; charset=utf-8"}
 // Render (XML) 写入 ContentType 和数据
 func (r XML) Render(w http.ResponseWriter) error {
 	r.WriteContentType(w)
+	if r.Header {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+	}
 	return xml.NewEncoder(w).Encode(r.Data)
 }
 
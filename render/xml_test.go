@@ -0,0 +1,41 @@
+package render
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type xmlUser struct {
+	XMLName xml.Name `xml:"user"`
+	Name    string   `xml:"name"`
+}
+
+func TestXMLDeclaration(t *testing.T) {
+	const body = "<user><name>web</name></user>"
+	tests := []struct {
+		header bool
+		want   string
+	}{
+		{false, body},
+		{true, `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + body},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		if err := (XML{Data: xmlUser{Name: "web"}, Header: tt.header}).Render(w); err != nil {
+			t.Fatal(err)
+		}
+		if w.Body.String() != tt.want {
+			t.Errorf("Header=%v: body = %q, want %q", tt.header, w.Body.String(), tt.want)
+		}
+		if ctype := w.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "application/xml") {
+			t.Errorf("Header=%v: Content-Type = %q", tt.header, ctype)
+		}
+
+		var decoded xmlUser
+		if err := xml.Unmarshal(w.Body.Bytes(), &decoded); err != nil || decoded.Name != "web" {
+			t.Errorf("Header=%v: round-trip = %+v, %v", tt.header, decoded, err)
+		}
+	}
+}
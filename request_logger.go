@@ -0,0 +1,71 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestLoggerKey 请求范围的日志记录器在上下文中的存储键.
+const RequestLoggerKey = "_lierbai/web/requestloggerkey"
+
+// RequestLogger 请求范围的日志记录器,处理程序通过With累积键值字段,由RequestLogging在请求结束时合并为一行输出.
+type RequestLogger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	fields []string
+}
+
+// With 添加一个键值字段,返回记录器本身以便链式调用.
+func (l *RequestLogger) With(key string, value interface{}) *RequestLogger {
+	l.mu.Lock()
+	l.fields = append(l.fields, fmt.Sprintf("%s=%v", key, value))
+	l.mu.Unlock()
+	return l
+}
+
+// Fields 返回已累积的字段(key=value格式).
+func (l *RequestLogger) Fields() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.fields...)
+}
+
+// Logger 返回当前请求的日志记录器,不存在时创建一个写入DefaultWriter的记录器.
+// 累积的字段只有在使用了RequestLogging中间件时才会输出.
+func (c *Context) Logger() *RequestLogger {
+	if l, ok := c.Get(RequestLoggerKey); ok {
+		if logger, ok := l.(*RequestLogger); ok {
+			return logger
+		}
+	}
+	logger := &RequestLogger{out: DefaultWriter}
+	c.Set(RequestLoggerKey, logger)
+	return logger
+}
+
+// RequestLogging 返回为每个请求创建RequestLogger的中间件,c.Next()后将请求信息和累积的字段合并为一行写入out(为nil时使用DefaultWriter).
+// 字段同时存储在c.Keys中,Logger中间件的自定义格式器可以通过LogFormatterParams.Keys[RequestLoggerKey]读取.
+func RequestLogging(out io.Writer) HandlerFunc {
+	return func(c *Context) {
+		w := out
+		if w == nil {
+			w = DefaultWriter
+		}
+		start := time.Now()
+		logger := &RequestLogger{out: w}
+		c.Set(RequestLoggerKey, logger)
+
+		c.Next()
+
+		line := fmt.Sprintf("%v | %3d | %13v | %s %s",
+			timeFormat(start), c.Writer.Status(), time.Since(start),
+			c.Request.Method, c.Request.URL.Path)
+		if fields := logger.Fields(); len(fields) > 0 {
+			line += " | " + strings.Join(fields, " ")
+		}
+		fmt.Fprintln(logger.out, line) // nolint: errcheck
+	}
+}
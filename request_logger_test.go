@@ -0,0 +1,46 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRequestLoggingCombinesFields(t *testing.T) {
+	var out bytes.Buffer
+	r := New()
+	r.Use(RequestLogging(&out), func(c *Context) {
+		c.Logger().With("user", "alice")
+		c.Next()
+	})
+	r.GET("/orders/:id", func(c *Context) {
+		c.Logger().With("order", c.Param("id")).With("cached", true)
+		c.Status(http.StatusCreated)
+	})
+
+	performRequest(r, http.MethodGet, "/orders/42")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d log lines, want 1: %q", len(lines), out.String())
+	}
+	line := lines[0]
+	for _, part := range []string{"| 201 |", "GET /orders/42", "| user=alice order=42 cached=true"} {
+		if !strings.Contains(line, part) {
+			t.Errorf("log line %q does not contain %q", line, part)
+		}
+	}
+}
+
+func TestContextLoggerWithoutMiddleware(t *testing.T) {
+	c := newTestContext(nil)
+	logger := c.Logger().With("a", 1)
+	if c.Logger() != logger {
+		t.Error("Logger() returned a different logger within the same request")
+	}
+	if got, want := logger.Fields(), []string{"a=1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %q, want %q", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 )
 
 const (
@@ -28,8 +29,10 @@ type ResponseWriter interface {
 
 type responseWriter struct {
 	http.ResponseWriter
-	size   int
-	status int
+	size        int
+	status      int
+	onError     func(error) // 写入失败时的回调
+	errReported bool        // 本次请求是否已回调过写入错误
 }
 
 var _ ResponseWriter = &responseWriter{}
@@ -38,17 +41,29 @@ func (w *responseWriter) reset(writer http.ResponseWriter) {
 	w.ResponseWriter = writer
 	w.size = noWritten
 	w.status = defaultStatus
+	w.errReported = false
 }
 
+// reportError 每个请求只回调一次写入错误.
+func (w *responseWriter) reportError(err error) {
+	if err != nil && !w.errReported && w.onError != nil {
+		w.errReported = true
+		w.onError(err)
+	}
+}
+
+// WriteHeader 设置响应状态码.headers写入后再修改状态码将被忽略(debug模式下输出警告).
 func (w *responseWriter) WriteHeader(code int) {
 	if code > 0 && w.status != code {
 		if w.Written() {
-			debugPrint("[WARNING] Headers已写入. 想用状态码 %d 覆盖 %d", w.status, code)
+			debugPrint("[WARNING] Headers已写入. 忽略用状态码 %d 覆盖 %d", code, w.status)
+			return
 		}
 		w.status = code
 	}
 }
 
+// WriteHeaderNow 强制写入状态码和headers,重复调用只会写入一次.
 func (w *responseWriter) WriteHeaderNow() {
 	if !w.Written() {
 		w.size = 0
@@ -60,6 +75,7 @@ func (w *responseWriter) Write(data []byte) (n int, err error) {
 	w.WriteHeaderNow()
 	n, err = w.ResponseWriter.Write(data)
 	w.size += n
+	w.reportError(err)
 	return
 }
 
@@ -67,6 +83,7 @@ func (w *responseWriter) WriteString(s string) (n int, err error) {
 	w.WriteHeaderNow()
 	n, err = io.WriteString(w.ResponseWriter, s)
 	w.size += n
+	w.reportError(err)
 	return
 }
 
@@ -101,6 +118,70 @@ func (w *responseWriter) Flush() {
 	w.ResponseWriter.(http.Flusher).Flush()
 }
 
+// headWriter 用于HEAD响应:转发headers和状态码,丢弃响应体并统计字节数,在finish时才写入headers.
+// 流式处理程序Flush时headers立即写出,此后CloseNotify的通道关闭,Stream等循环随即结束.
+type headWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+	done        chan bool
+}
+
+func newHeadWriter(w http.ResponseWriter) *headWriter {
+	return &headWriter{ResponseWriter: w, status: defaultStatus, done: make(chan bool)}
+}
+
+func (w *headWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *headWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	return len(data), nil
+}
+
+// Flush 实现http.Flusher接口.首次调用时写入状态码和headers(流式响应不设置Content-Length)并刷新.
+func (w *headWriter) Flush() {
+	if w.wroteHeader {
+		return
+	}
+	w.writeHeader()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 实现http.Hijacker接口,接管连接后finish不再写入headers.
+func (w *headWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// CloseNotify 实现http.CloseNotify接口.HEAD响应没有响应体,headers写出后通道即关闭;
+// 客户端断开连接需要通过c.Request.Context()判断.
+func (w *headWriter) CloseNotify() <-chan bool {
+	return w.done
+}
+
+// finish 未设置Content-Length时按丢弃的字节数设置,然后写入状态码和headers(已写出时不做任何事).
+func (w *headWriter) finish() {
+	if w.wroteHeader {
+		return
+	}
+	header := w.ResponseWriter.Header()
+	if w.size > 0 && header.Get("Content-Length") == "" && bodyAllowedForStatus(w.status) {
+		header.Set("Content-Length", strconv.Itoa(w.size))
+	}
+	w.writeHeader()
+}
+
+func (w *headWriter) writeHeader() {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+	close(w.done)
+}
+
 func (w *responseWriter) Pusher() (pusher http.Pusher) {
 	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
 		return pusher
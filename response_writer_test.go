@@ -0,0 +1,205 @@
+package web
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeadResponseMatchesGetWithoutBody(t *testing.T) {
+	r := New()
+	r.AutoHEAD = true
+	r.GET("/doc", func(c *Context) {
+		c.Header("X-Doc", "1")
+		c.String(http.StatusOK, "hello world")
+	})
+
+	get := performRequest(r, http.MethodGet, "/doc")
+	head := performRequest(r, http.MethodHead, "/doc")
+	if head.Code != get.Code {
+		t.Errorf("HEAD status = %d, GET status = %d", head.Code, get.Code)
+	}
+	for _, key := range []string{"Content-Type", "X-Doc"} {
+		if head.Header().Get(key) != get.Header().Get(key) {
+			t.Errorf("HEAD %s = %q, GET %s = %q", key, head.Header().Get(key), key, get.Header().Get(key))
+		}
+	}
+	if got := head.Header().Get("Content-Length"); got != "11" {
+		t.Errorf("HEAD Content-Length = %q, want 11", got)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("HEAD body = %q, want empty", head.Body.String())
+	}
+}
+
+func TestHeadStreamingHandlerStops(t *testing.T) {
+	r := New()
+	r.HEAD("/events", func(c *Context) {
+		steps := 0
+		c.Stream(func(w io.Writer) bool {
+			steps++
+			io.WriteString(w, "data: tick\n\n") // nolint: errcheck
+			return steps < 1000
+		})
+		c.Header("X-Steps", "late")
+		if steps != 1 {
+			t.Errorf("stream steps = %d, want 1", steps)
+		}
+	})
+	r.GET("/events", func(c *Context) {})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	resp, err := http.Head(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Content-Length") != "" || resp.Header.Get("X-Steps") != "" {
+		t.Errorf("headers after flush = %v", resp.Header)
+	}
+}
+
+func TestHeadWriterHijack(t *testing.T) {
+	r := New()
+	r.HEAD("/upgrade", func(c *Context) {
+		conn, buf, err := c.Writer.Hijack()
+		if err != nil {
+			t.Errorf("Hijack error: %v", err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 204 No Content\r\nX-Hijacked: 1\r\n\r\n") // nolint: errcheck
+		buf.Flush()                                                         // nolint: errcheck
+	})
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	conn, err := net.DialTimeout("tcp", server.Listener.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "HEAD /upgrade HTTP/1.1\r\nHost: test\r\n\r\n") // nolint: errcheck
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodHead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("X-Hijacked") != "1" {
+		t.Errorf("hijacked response = %d %v", resp.StatusCode, resp.Header)
+	}
+	rest, _ := io.ReadAll(resp.Body)
+	if strings.TrimSpace(string(rest)) != "" {
+		t.Errorf("unexpected data after hijacked response: %q", rest)
+	}
+}
+
+func TestStatusIgnoredAfterWrite(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("body") // nolint: errcheck
+		c.Status(http.StatusInternalServerError)
+		c.Writer.WriteHeader(http.StatusBadGateway)
+		if c.Writer.Status() != http.StatusOK {
+			t.Errorf("Status() after write = %d, want 200", c.Writer.Status())
+		}
+	})
+	w := performRequest(r, http.MethodGet, "/")
+	if w.Code != http.StatusOK || w.Body.String() != "body" {
+		t.Errorf("response = %d %q, want 200 body", w.Code, w.Body.String())
+	}
+}
+
+// countingWriter 统计写入的字节数并给写入的数据加上前缀.
+type countingWriter struct {
+	ResponseWriter
+	prefix string
+	n      *int
+}
+
+func (w *countingWriter) Write(data []byte) (int, error) {
+	*w.n += len(data)
+	return w.ResponseWriter.Write(append([]byte(w.prefix), data...))
+}
+
+func (w *countingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func TestContextWrapWriterComposes(t *testing.T) {
+	var outer, inner int
+	var base ResponseWriter
+	r := New()
+	r.Use(func(c *Context) {
+		if c.Writer != &c.writermem {
+			t.Error("c.Writer was not reset to the base writer")
+		}
+		base = c.Writer
+		c.WrapWriter(func(w ResponseWriter) ResponseWriter { return &countingWriter{w, "a:", &outer} })
+		c.WrapWriter(func(w ResponseWriter) ResponseWriter { return &countingWriter{w, "b:", &inner} })
+	})
+	r.GET("/", func(c *Context) { c.String(http.StatusOK, "x") })
+
+	for i := 0; i < 2; i++ {
+		outer, inner = 0, 0
+		w := performRequest(r, http.MethodGet, "/")
+		if w.Body.String() != "a:b:x" {
+			t.Errorf("body = %q, want both wrappers applied", w.Body.String())
+		}
+		if inner != 1 || outer != 3 {
+			t.Errorf("inner saw %d bytes, outer saw %d bytes", inner, outer)
+		}
+		if base.Size() != 5 {
+			t.Errorf("base writer size = %d", base.Size())
+		}
+	}
+}
+
+// failingWriter 每次写入响应体都返回errBrokenPipe.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+var errBrokenPipe = errors.New("write: broken pipe")
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errBrokenPipe }
+
+func TestCentreOnWriteErrorFiresOnce(t *testing.T) {
+	var calls int
+	var got error
+	r := New()
+	r.OnWriteError = func(c *Context, err error) {
+		calls++
+		got = err
+	}
+	r.GET("/", func(c *Context) {
+		c.Writer.Write([]byte("a")) // nolint: errcheck
+		c.Writer.Write([]byte("b")) // nolint: errcheck
+		c.Writer.WriteString("c")   // nolint: errcheck
+		c.String(http.StatusOK, "d")
+	})
+
+	for i := 0; i < 2; i++ {
+		calls, got = 0, nil
+		r.ServeHTTP(failingWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+		if calls != 1 || got != errBrokenPipe {
+			t.Errorf("request %d: OnWriteError called %d times with %v, want once", i, calls, got)
+		}
+	}
+
+	calls = 0
+	performRequest(r, http.MethodGet, "/")
+	if calls != 0 {
+		t.Errorf("OnWriteError called %d times for a successful write", calls)
+	}
+}
@@ -1,5 +1,7 @@
 package web
 
+import "time"
+
 // HandlerFunc 定义中间件的handler为返回值.
 type HandlerFunc func(*Context)
 
@@ -24,3 +26,47 @@ type Route struct {
 
 // Routes defines a RouteInfo array.
 type Routes []Route
+
+// RouteOption 路由注册选项,用于HandleWithOptions.
+type RouteOption func(*routeOptions)
+
+type routeOptions struct {
+	unescape unescapeMode
+	timeout  time.Duration
+	host     string // Host()跳板的pattern,注册到该host的路由树
+}
+
+// WithUnescape 覆盖全局的UnescapePathValues,单独指定该路由的参数值是否转义.
+// 与UnescapePathValues相同,仅在UseRawPath为true时生效.
+func WithUnescape(unescape bool) RouteOption {
+	return func(opts *routeOptions) {
+		opts.unescape = unescapeNever
+		if unescape {
+			opts.unescape = unescapeAlways
+		}
+	}
+}
+
+// WithTimeout 为该路由的请求附加d后超时的context(同WithDeadline中间件).
+// 超时是协作式的:处理程序应检查c.Request.Context().Done()并返回,处理程序链返回时已超时且尚未写入响应的返回503.
+// router.HandleWithOptions("GET", "/slow", []web.RouteOption{web.WithTimeout(5 * time.Second)}, handler)
+func WithTimeout(d time.Duration) RouteOption {
+	return func(opts *routeOptions) {
+		opts.timeout = d
+	}
+}
+
+// onHost 将路由注册到匹配pattern的host路由树,由Host()跳板使用.
+func onHost(pattern string) RouteOption {
+	return func(opts *routeOptions) {
+		opts.host = pattern
+	}
+}
+
+func newRouteOptions(opts []RouteOption) routeOptions {
+	var options routeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
@@ -0,0 +1,45 @@
+package web
+
+import "net/http"
+
+// TxKey 事务在上下文中的存储键.
+const TxKey = "_lierbai/web/txkey"
+
+// Tx 请求范围事务需要实现的最小接口(如*sql.Tx).
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Transaction 返回管理请求范围事务的中间件.
+// 由begin开启事务并存储到上下文(TxKey),c.Next()后响应状态码>=400或发生panic时回滚,否则提交.
+// 开启事务失败将返回500并中止,提交|回滚失败的错误会附加到c.Errors.
+func Transaction(begin func(*Context) (Tx, error)) HandlerFunc {
+	return func(c *Context) {
+		tx, err := begin(c)
+		if err != nil {
+			c.AbortWithError(http.StatusInternalServerError, err) // nolint: errcheck
+			return
+		}
+		c.Set(TxKey, tx)
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				if err := tx.Rollback(); err != nil {
+					c.Error(err) // nolint: errcheck
+				}
+				panic(rec)
+			}
+		}()
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			err = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+		if err != nil {
+			c.Error(err) // nolint: errcheck
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+type testTx struct {
+	committed, rolledBack bool
+}
+
+func (tx *testTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func TestTransaction(t *testing.T) {
+	var tx *testTx
+	r := New()
+	r.Use(Recovery())
+	r.Use(Transaction(func(c *Context) (Tx, error) {
+		if c.Query("fail") != "" {
+			return nil, errors.New("begin failed")
+		}
+		tx = &testTx{}
+		return tx, nil
+	}))
+	r.GET("/ok", func(c *Context) {
+		if got, _ := c.Get(TxKey); got != tx {
+			t.Errorf("context tx = %v, want %v", got, tx)
+		}
+		c.Status(http.StatusCreated)
+	})
+	r.GET("/bad", func(c *Context) { c.Status(http.StatusConflict) })
+	r.GET("/panic", func(c *Context) { panic("boom") })
+
+	tests := []struct {
+		path                  string
+		code                  int
+		committed, rolledBack bool
+	}{
+		{"/ok", http.StatusCreated, true, false},
+		{"/bad", http.StatusConflict, false, true},
+		{"/panic", http.StatusInternalServerError, false, true},
+	}
+	for _, tt := range tests {
+		tx = nil
+		w := performRequest(r, http.MethodGet, tt.path)
+		if w.Code != tt.code || tx == nil || tx.committed != tt.committed || tx.rolledBack != tt.rolledBack {
+			t.Errorf("%s = %d, tx %+v, want %d committed=%v rolledBack=%v", tt.path, w.Code, tx, tt.code, tt.committed, tt.rolledBack)
+		}
+	}
+
+	tx = nil
+	if w := performRequest(r, http.MethodGet, "/ok?fail=1"); w.Code != http.StatusInternalServerError || tx != nil {
+		t.Errorf("begin failure = %d, tx %v", w.Code, tx)
+	}
+}
@@ -1,11 +1,15 @@
 package web
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"path"
 	"reflect"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -44,12 +48,46 @@ func WrapH(h http.Handler) HandlerFunc {
 	}
 }
 
+// WrapFSafe 同WrapF,但会恢复被封装函数中的panic,并通过c.AbortWithError(500, err)记录.
+func WrapFSafe(f http.HandlerFunc) HandlerFunc {
+	return WrapHSafe(f)
+}
+
+// WrapHSafe 同WrapH,但会恢复被封装处理程序中的panic,并通过c.AbortWithError(500, err)记录.
+func WrapHSafe(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				c.AbortWithError(http.StatusInternalServerError, panicError(rec)) // nolint: errcheck
+			}
+		}()
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// panicError 将recover()得到的值转换为error.
+func panicError(rec interface{}) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", rec)
+}
+
 func assert1(guard bool, text string) {
 	if !guard {
 		panic(text)
 	}
 }
 
+func assertMethod(httpMethod string) {
+	if matches, err := regexp.MatchString("^[A-Z]+$", httpMethod); !matches || err != nil {
+		panic("http method " + httpMethod + " is not valid")
+	}
+}
+
 func filterFlags(content string) string {
 	for i, char := range content {
 		if char == ' ' || char == ';' {
@@ -69,17 +107,73 @@ func chooseData(custom, wildcard interface{}) interface{} {
 	return custom
 }
 
+// parseAccept 解析Accept header,按q值从高到低(q值相同时保持原顺序)返回格式列表,忽略q=0的格式.
 func parseAccept(acceptHeader string) []string {
 	parts := strings.Split(acceptHeader, ",")
 	out := make([]string, 0, len(parts))
+	qs := make(map[string]float64, len(parts))
 	for _, part := range parts {
-		if part = strings.TrimSpace(strings.Split(part, ";")[0]); part != "" {
-			out = append(out, part)
+		params := strings.Split(part, ";")
+		mime := strings.TrimSpace(params[0])
+		if mime == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if k, v := splitParam(param); k == "q" {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
 		}
+		if q <= 0 {
+			continue
+		}
+		if _, ok := qs[mime]; !ok {
+			out = append(out, mime)
+		}
+		qs[mime] = q
 	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return qs[out[i]] > qs[out[j]]
+	})
 	return out
 }
 
+func splitParam(param string) (string, string) {
+	kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+	if len(kv) != 2 {
+		return kv[0], ""
+	}
+	return strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+}
+
+// matchAccept 判断offer是否满足客户端接受的格式accepted(支持'*'通配).
+// 根据RFC 2616 和 RFC 2396, non-ASCII 不允许被用于headers中,所以可以在字符串上迭代,不必转为[]rune
+func matchAccept(accepted, offer string) bool {
+	for i := 0; i < len(accepted); i++ {
+		if i >= len(offer) {
+			return false
+		}
+		if accepted[i] == '*' || offer[i] == '*' {
+			return true
+		}
+		if accepted[i] != offer[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func lastChar(str string) uint8 {
 	if str == "" {
 		panic("The length of the string can't be 0")
@@ -87,8 +181,36 @@ func lastChar(str string) uint8 {
 	return str[len(str)-1]
 }
 
+// namedPC Named返回的闭包的代码指针,nameOfFunction据此识别命名的handler.
+var namedPC = reflect.ValueOf(Named("", nil)).Pointer()
+
+// Named 返回携带名称的handler,HandlerName()|Routes()|调试输出将使用该名称(常用于匿名函数).
+// router.GET("/ping", web.Named("ping", func(c *web.Context) {...}))
+//
+// 名称只能保存在返回的闭包中:Named必须返回HandlerFunc,无法换成包装类型;
+// 而以闭包为键的全局表需要闭包的身份,reflect只能给出所有Named闭包共享的代码指针,取得闭包本身只能借助unsafe.
+// 因此nameOfFunction以namedOut非nil的探测Context调用闭包,闭包写出名称后立即返回,不会调用h.
+// 禁止内联以保证所有调用返回同一函数字面量的闭包.
+//
+//go:noinline
+func Named(name string, h HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		if c.namedOut != nil {
+			*c.namedOut = name
+			return
+		}
+		h(c)
+	}
+}
+
 func nameOfFunction(f interface{}) string {
-	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	pc := reflect.ValueOf(f).Pointer()
+	if h, ok := f.(HandlerFunc); ok && h != nil && pc == namedPC {
+		var name string
+		h(&Context{namedOut: &name})
+		return name
+	}
+	return runtime.FuncForPC(pc).Name()
 }
 
 func joinPaths(absolutePath, relativePath string) string {
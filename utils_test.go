@@ -0,0 +1,80 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNamedHandlersInRoutes(t *testing.T) {
+	r := New()
+	var handled, handlerName string
+	for _, name := range []string{"users", "orders"} {
+		name := name
+		// 同一函数字面量的闭包,名称必须互不影响
+		r.GET("/"+name, Named(name+"Handler", func(c *Context) {
+			handled, handlerName = name, c.HandlerName()
+		}))
+	}
+	r.GET("/plain", func(c *Context) {})
+
+	want := map[string]string{"/users": "usersHandler", "/orders": "ordersHandler"}
+	for _, route := range r.Routes() {
+		if name, ok := want[route.Path]; ok && route.Handler != name {
+			t.Errorf("route %s handler = %q, want %q", route.Path, route.Handler, name)
+		}
+		if route.Path == "/plain" && route.Handler == "" {
+			t.Error("unnamed handler has empty name")
+		}
+	}
+
+	performRequest(r, http.MethodGet, "/orders")
+	if handled != "orders" || handlerName != "ordersHandler" {
+		t.Errorf("handled %q with HandlerName %q", handled, handlerName)
+	}
+}
+
+func TestWrapSafeRecoversPanics(t *testing.T) {
+	panicking := func(w http.ResponseWriter, req *http.Request) { panic("legacy failure") }
+	var errs []string
+	r := New()
+	r.Use(func(c *Context) {
+		c.Next()
+		errs = c.Errors.Errors()
+	})
+	r.GET("/f", WrapFSafe(panicking))
+	r.GET("/h", WrapHSafe(http.HandlerFunc(panicking)))
+	r.GET("/ok", WrapFSafe(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("fine")) })) // nolint: errcheck
+
+	for _, path := range []string{"/f", "/h"} {
+		errs = nil
+		w := performRequest(r, http.MethodGet, path)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s status = %d, want 500", path, w.Code)
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0], "legacy failure") {
+			t.Errorf("%s recorded errors = %v", path, errs)
+		}
+	}
+	if w := performRequest(r, http.MethodGet, "/ok"); w.Code != http.StatusOK || w.Body.String() != "fine" {
+		t.Errorf("/ok = %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestNamedNeverCallsHandlerForName(t *testing.T) {
+	calls := 0
+	h := Named("counted", func(c *Context) { calls++ })
+	if name := nameOfFunction(h); name != "counted" {
+		t.Errorf("nameOfFunction = %q, want counted", name)
+	}
+	r := New()
+	r.GET("/", h)
+	r.Routes()
+	if calls != 0 {
+		t.Errorf("handler called %d times while reading its name", calls)
+	}
+	performRequest(r, http.MethodGet, "/")
+	if calls != 1 {
+		t.Errorf("handler called %d times for one request", calls)
+	}
+}
@@ -1,13 +1,20 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
+	"io/fs"
 	"net"
 	"net/http"
 	"os"
 	"path"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/lierbai/web/internal/bytesconv"
 	"github.com/lierbai/web/render"
@@ -18,29 +25,78 @@ const defaultMultipartMemory = 32 << 20 // 32 MB
 var (
 	default404Body   = []byte("404 page not found")
 	default405Body   = []byte("405 method not allowed")
+	default414Body   = []byte("414 request URI too long")
 	defaultAppCentre bool
 )
 
+// 受信任平台提供客户端IP的header名称,用于Centre.TrustedPlatform.
+const (
+	PlatformGoogleAppEngine = "X-Appengine-Remote-Addr" // Google App Engine
+	PlatformCloudflare      = "CF-Connecting-IP"        // Cloudflare
+)
+
+// TrailingSlashPolicy 请求路径与路由只差结尾的'/'时的处理方式.
+type TrailingSlashPolicy uint8
+
+const (
+	TrailingSlashRedirect TrailingSlashPolicy = iota // 重定向到已注册的路径(需开启RedirectTrailingSlash,否则返回404)
+	TrailingSlashStrict                              // 视为不同的路径,返回404
+	TrailingSlashIgnore                              // 直接执行已注册路径的处理程序,不重定向
+)
+
 // Centre 中枢
 type Centre struct {
 	Boarder
-	AppCentre              bool              //
-	RedirectTrailingSlash  bool              // 反斜杠结尾路径自动重定向
-	HandleMethodNotAllowed bool              // 请求体内部转递
-	ForwardedByClientIP    bool              // 转发连接IP
-	UseRawPath             bool              // url.RawPath查找参数
-	UnescapePathValues     bool              // 不转义,使用url.Path
-	RemoveExtraSlash       bool              // 是否删除额外的反斜杠
-	MaxMultipartMemory     int64             // 表单上传最大限制
-	delims                 render.Delims     // 模板参数识别分隔符
-	HTMLRender             render.HTMLRender // 返回渲染模板的接口
-	FuncMap                template.FuncMap  // 名称到函数的映射
-	pool                   sync.Pool         // 线程安全队列
-	allNoRoute             HandlersChain     //
-	allNoMethod            HandlersChain     //
-	noRoute                HandlersChain     //
-	noMethod               HandlersChain     //
-	trees                  methodTrees       // 路径节点树
+	AppCentre                   bool                                   //
+	RedirectTrailingSlash       bool                                   // 反斜杠结尾路径自动重定向
+	TrailingSlashPolicy         TrailingSlashPolicy                    // 只差结尾'/'时的处理方式,默认TrailingSlashRedirect
+	CaseInsensitive             bool                                   // 精确匹配失败时不区分大小写匹配路由(不重定向)
+	HandleMethodNotAllowed      bool                                   // 请求体内部转递
+	NormalizeMethod             bool                                   // 查找路由前将请求方法转为大写(兼容发送小写方法的客户端)
+	AutoHEAD                    bool                                   // HEAD请求没有对应处理程序时使用GET路由处理,并丢弃响应体
+	DisableContextPool          bool                                   // 每个请求分配新的上下文且不放回池中(用于调试在请求外持有上下文的问题)
+	ForwardedByClientIP         bool                                   // 转发连接IP
+	RemoteIPHeaders             []string                               // ForwardedByClientIP时按顺序查找客户端IP的headers;默认不含Forwarded,加入后按RFC 7239解析for=
+	TrustForwardedOnlyOnTLS     bool                                   // 只在TLS连接上信任携带客户端IP的headers(含TrustedPlatform),只影响ClientIP,框架本身不读取X-Forwarded-Proto
+	TrustedPlatform             string                                 // 受信任平台的客户端IP header(如PlatformCloudflare),优先于转发header
+	UseRawPath                  bool                                   // url.RawPath查找参数
+	UnescapePathValues          bool                                   // 不转义,使用url.Path
+	RemoveExtraSlash            bool                                   // 是否删除额外的反斜杠
+	JSONContentLength           bool                                   // JSON响应(c.JSON等)先完整序列化并设置Content-Length
+	MaxMultipartMemory          int64                                  // 表单上传最大限制
+	MaxURILength                int                                    // 请求URI的最大长度,超过时返回414,0表示不限制
+	DefaultSameSite             http.SameSite                          // 每个请求默认的Cookie SameSite,可用c.SetSameSite()覆盖
+	OnWriteError                func(*Context, error)                  // 写入响应体失败时调用(如broken pipe),每个请求最多一次
+	BindErrorRenderer           func(*Context, error)                  // 设置后MustBindWith(BindJSON等)绑定失败时由它渲染响应,代替默认的400空响应体
+	OnRouteRegister             func(method, path, handlerName string) // 每注册一个路由时调用(与调试模式无关)
+	TracerHook                  *TracerHook                            // 链路追踪钩子,为nil时不调用
+	NotFoundBody                []byte                                 // 404响应体,默认"404 page not found"
+	NotFoundContentType         string                                 // 404响应体的Content-Type,默认text/plain
+	MethodNotAllowedBody        []byte                                 // 405响应体,默认"405 method not allowed"
+	MethodNotAllowedContentType string                                 // 405响应体的Content-Type,默认text/plain
+	delims                      render.Delims                          // 模板参数识别分隔符
+	HTMLRender                  render.HTMLRender                      // 返回渲染模板的接口
+	FuncMap                     template.FuncMap                       // 名称到函数的映射
+	pool                        sync.Pool                              // 线程安全队列
+	allNoRoute                  HandlersChain                          //
+	allNoMethod                 HandlersChain                          //
+	noRoute                     HandlersChain                          //
+	noMethod                    HandlersChain                          //
+	trees                       methodTrees                            // 路径节点树
+	hosts                       []hostTrees                            // Host()跳板的路由树,按注册顺序匹配
+	errorStatuses               errorStatuses                          // 错误到HTTP状态码的映射
+	onRequestStart              []func(*Context)                       // 每个请求处理前调用
+	onRequestEnd                []func(*Context)                       // 每个请求处理后调用
+	serversMu                   sync.Mutex                             // 保护servers
+	servers                     []*http.Server                         // Run*启动的服务器,用于Shutdown
+	poolStats                   *PoolStats                             // 上下文池的计数器(原子操作)
+}
+
+// PoolStats 上下文池的统计计数.
+type PoolStats struct {
+	Gets uint64 // 从池中取出上下文的次数(每个请求一次)
+	Puts uint64 // 放回池中的次数
+	News uint64 // 新分配上下文的次数,池预热后应趋于稳定
 }
 
 // New 返回未附加任何中间件的Centre实例
@@ -52,19 +108,25 @@ func New() *Centre {
 			basePath: "/",
 			root:     true,
 		},
-		RedirectTrailingSlash:  true,
-		HandleMethodNotAllowed: false,
-		ForwardedByClientIP:    true,
-		UseRawPath:             false,
-		UnescapePathValues:     true,
-		RemoveExtraSlash:       false,
-		AppCentre:              defaultAppCentre,
-		MaxMultipartMemory:     defaultMultipartMemory, // 32 MB
-		delims:                 render.Delims{Left: "{{", Right: "}}"},
-		FuncMap:                template.FuncMap{},
-		trees:                  make(methodTrees, 0, 9),
+		RedirectTrailingSlash:       true,
+		HandleMethodNotAllowed:      false,
+		ForwardedByClientIP:         true,
+		RemoteIPHeaders:             []string{"X-Forwarded-For", "X-Real-Ip"},
+		UseRawPath:                  false,
+		UnescapePathValues:          true,
+		RemoveExtraSlash:            false,
+		AppCentre:                   defaultAppCentre,
+		MaxMultipartMemory:          defaultMultipartMemory, // 32 MB
+		NotFoundBody:                default404Body,
+		NotFoundContentType:         MIMEPlain,
+		MethodNotAllowedBody:        default405Body,
+		MethodNotAllowedContentType: MIMEPlain,
+		delims:                      render.Delims{Left: "{{", Right: "}}"},
+		FuncMap:                     template.FuncMap{},
+		trees:                       make(methodTrees, 0, 9),
 	}
 	centre.Boarder.centre = centre
+	centre.poolStats = new(PoolStats)
 	centre.pool.New = func() interface{} {
 		return centre.allocateContext()
 	}
@@ -80,7 +142,23 @@ func Default() *Centre {
 }
 
 func (centre *Centre) allocateContext() *Context {
-	return &Context{centre: centre}
+	atomic.AddUint64(&centre.poolStats.News, 1)
+	c := &Context{centre: centre}
+	c.writermem.onError = func(err error) {
+		if centre.OnWriteError != nil {
+			centre.OnWriteError(c, err)
+		}
+	}
+	return c
+}
+
+// PoolStats 返回上下文池统计计数的快照,可用于调试处理程序中诊断内存分配.
+func (centre *Centre) PoolStats() PoolStats {
+	return PoolStats{
+		Gets: atomic.LoadUint64(&centre.poolStats.Gets),
+		Puts: atomic.LoadUint64(&centre.poolStats.Puts),
+		News: atomic.LoadUint64(&centre.poolStats.News),
+	}
 }
 
 // Delims 设置模板变量的左右分隔符并返回实例
@@ -112,6 +190,13 @@ func (centre *Centre) LoadHTMLFiles(files ...string) {
 
 }
 
+// LoadHTMLFS 从fs.FS(如embed.FS)加载由patterns标识的HTML文件,并将结果与HTML呈现器关联
+func (centre *Centre) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	templ := template.Must(template.New("").Delims(centre.delims.Left, centre.delims.Right).Funcs(centre.FuncMap).ParseFS(fsys, patterns...))
+	debugPrintLoadTemplate(templ)
+	centre.SetHTMLTemplate(templ)
+}
+
 // SetHTMLTemplate 将模板与HTML呈现器关联.
 func (centre *Centre) SetHTMLTemplate(templ *template.Template) {
 	if len(centre.trees) > 0 {
@@ -138,6 +223,31 @@ func (centre *Centre) NoMethod(handlers ...HandlerFunc) {
 	centre.rebuild405Handlers()
 }
 
+// OnRequestStart 注册在每个请求处理前调用的钩子(包括404|405),按注册顺序调用.
+// 与中间件不同,它不在处理程序链中,不受Abort影响.
+func (centre *Centre) OnRequestStart(fn func(*Context)) {
+	centre.onRequestStart = append(centre.onRequestStart, fn)
+}
+
+// OnRequestEnd 注册在每个请求处理后调用的钩子(包括404|405),按注册顺序调用.
+// 与中间件不同,它不在处理程序链中,不受Abort影响.
+func (centre *Centre) OnRequestEnd(fn func(*Context)) {
+	centre.onRequestEnd = append(centre.onRequestEnd, fn)
+}
+
+// TracerHook 链路追踪的集成点,不依赖任何追踪库(如OpenTelemetry).
+type TracerHook struct {
+	// Start 在每个请求处理前调用(此时尚未匹配路由),返回的函数在请求处理后调用(可为nil).
+	// 返回的函数调用时c.FullPath()已可用于命名span,未匹配的路由为"".
+	Start func(c *Context) (end func())
+}
+
+// RegisterErrorStatus 注册错误对应的HTTP状态码,供c.JSONError()使用.
+// 使用errors.Is匹配,按注册顺序查找.
+func (centre *Centre) RegisterErrorStatus(target error, code int) {
+	centre.errorStatuses = append(centre.errorStatuses, errorStatus{target: target, code: code})
+}
+
 // Use 将全局中间件连接到路由器. ie.通过 Use() 附加的中间件将包含在每个请求的处理程序链中. 甚至是 404, 405, 静态文件...
 // 这是日志记录器和错误管理中间件的好位置
 func (centre *Centre) Use(middleware ...HandlerFunc) IRoutes {
@@ -147,6 +257,11 @@ func (centre *Centre) Use(middleware ...HandlerFunc) IRoutes {
 	return centre
 }
 
+// UseWhen 添加只在pred返回true时执行的全局中间件,同Use.
+func (centre *Centre) UseWhen(pred func(*Context) bool, middleware ...HandlerFunc) IRoutes {
+	return centre.Use(conditional(pred, middleware)...)
+}
+
 func (centre *Centre) rebuild404Handlers() {
 	centre.allNoRoute = centre.combineHandlers(centre.noRoute)
 }
@@ -155,19 +270,58 @@ func (centre *Centre) rebuild405Handlers() {
 	centre.allNoMethod = centre.combineHandlers(centre.noMethod)
 }
 
-func (centre *Centre) addRoute(method, path string, handlers HandlersChain) {
+func (centre *Centre) addRoute(method, path string, handlers HandlersChain, opts ...RouteOption) {
 	assert1(path[0] == '/', "路径必须以'/'开头")
 	assert1(method != "", "HTTP method 不能为空")
 	assert1(len(handlers) > 0, "必须至少有一个处理程序")
 
+	options := newRouteOptions(opts)
+	if options.timeout > 0 {
+		assert1(len(handlers) < int(abortIndex)-1, "too many handlers")
+		handlers = append(HandlersChain{routeTimeout(options.timeout)}, handlers...)
+	}
+
 	debugPrintRoute(method, path, handlers)
-	root := centre.trees.get(method)
+	trees := &centre.trees
+	if options.host != "" {
+		trees = centre.hostTrees(options.host)
+	}
+	root := trees.get(method)
 	if root == nil {
 		root = new(node)
 		root.fullPath = "/"
-		centre.trees = append(centre.trees, methodTree{method: method, root: root})
+		*trees = append(*trees, methodTree{method: method, root: root})
+	}
+	leaf := root.addRoute(path, handlers)
+	leaf.unescape = options.unescape
+
+	if centre.OnRouteRegister != nil {
+		centre.OnRouteRegister(method, path, nameOfFunction(handlers.Last()))
 	}
-	root.addRoute(path, handlers)
+}
+
+// CanHandle 检查能否为method和path注册路由,不修改路由树.
+// 路径无效或与已注册的路由冲突时返回描述错误(addRoute会因同样的原因panic),否则返回nil.
+func (centre *Centre) CanHandle(method, path string) (err error) {
+	if method == "" {
+		return errors.New("HTTP method 不能为空")
+	}
+	if path == "" || path[0] != '/' {
+		return errors.New("路径必须以'/'开头")
+	}
+
+	root := new(node)
+	root.fullPath = "/"
+	if existing := centre.trees.get(method); existing != nil {
+		root = existing.clone()
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%v", rec)
+		}
+	}()
+	root.addRoute(path, HandlersChain{func(*Context) {}})
+	return nil
 }
 
 // Routes Routes
@@ -175,9 +329,123 @@ func (centre *Centre) Routes() (routes Routes) {
 	for _, tree := range centre.trees {
 		routes = iterate("", tree.method, routes, tree.root)
 	}
+	for _, host := range centre.hosts {
+		for _, tree := range host.trees {
+			routes = iterate("", tree.method, routes, tree.root)
+		}
+	}
 	return routes
 }
 
+// RouteManifestEntry RouteManifestHandler输出的单个路由.
+type RouteManifestEntry struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// RouteManifestHandler 返回以JSON数组输出已注册路由(method, path, handler,按path和method排序)的处理程序,供工具使用.
+// 提供清单的路由本身(当前请求的方法和路由路径)不包含在清单中.
+// router.GET("/_routes", router.RouteManifestHandler())
+func (centre *Centre) RouteManifestHandler() HandlerFunc {
+	return func(c *Context) {
+		routes := centre.Routes()
+		manifest := make([]RouteManifestEntry, 0, len(routes))
+		for _, route := range routes {
+			if route.Method == c.Request.Method && route.Path == c.FullPath() {
+				continue
+			}
+			manifest = append(manifest, RouteManifestEntry{Method: route.Method, Path: route.Path, Handler: route.Handler})
+		}
+		sort.Slice(manifest, func(i, j int) bool {
+			if manifest[i].Path != manifest[j].Path {
+				return manifest[i].Path < manifest[j].Path
+			}
+			return manifest[i].Method < manifest[j].Method
+		})
+		c.JSON(http.StatusOK, manifest)
+	}
+}
+
+// AllowedMethods 返回能处理给定路径(或路径模板,如"/user/:id")的HTTP方法(已排序),也用于405响应的Allow header.
+// 包括已注册的方法(含OPTIONS路由,如用于CORS的"/*path"),开启AutoHEAD时有GET即包括HEAD.
+func (centre *Centre) AllowedMethods(path string) []string {
+	var methods []string
+	hasGet, hasHead := false, false
+	for _, tree := range centre.trees {
+		if value := tree.root.getValue(path, nil, false, false); value.handlers != nil {
+			methods = append(methods, tree.method)
+			hasGet = hasGet || tree.method == http.MethodGet
+			hasHead = hasHead || tree.method == http.MethodHead
+		}
+	}
+	if hasGet && !hasHead && centre.AutoHEAD {
+		methods = append(methods, http.MethodHead)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// RemoveRoute 删除已注册的路由(method和注册时的路径模板,如"/user/:id"),返回是否找到并删除.
+// 默认路由树和所有Host()跳板的路由树都会被检查,同一模板在多处注册时全部删除.
+// 实现方式是用该方法的其余路由重建路由树,开销与路由数量成正比.
+// 它不是并发安全的,不能与请求处理同时进行;删除后该方法的其余路由和处理程序保持不变.
+func (centre *Centre) RemoveRoute(method, path string) bool {
+	removed := removeRoute(&centre.trees, method, path)
+	for i := range centre.hosts {
+		if removeRoute(&centre.hosts[i].trees, method, path) {
+			removed = true
+		}
+	}
+	return removed
+}
+
+// removeRoute 从trees中删除method的path路由并重建该方法的路由树,返回是否找到.
+func removeRoute(trees *methodTrees, method, path string) bool {
+	for i, tree := range *trees {
+		if tree.method != method {
+			continue
+		}
+		found := false
+		var remaining []*node
+		var paths []string
+		walkLeaves("", tree.root, func(fullPath string, leaf *node) {
+			if fullPath == path {
+				found = true
+				return
+			}
+			remaining = append(remaining, leaf)
+			paths = append(paths, fullPath)
+		})
+		if !found {
+			return false
+		}
+		if len(remaining) == 0 {
+			*trees = append((*trees)[:i], (*trees)[i+1:]...)
+			return true
+		}
+		root := new(node)
+		root.fullPath = "/"
+		for j, leaf := range remaining {
+			root.addRoute(paths[j], leaf.handlers).unescape = leaf.unescape
+		}
+		(*trees)[i].root = root
+		return true
+	}
+	return false
+}
+
+// walkLeaves 对树中每个注册了处理程序的节点调用fn,fullPath为该节点对应的路由路径.
+func walkLeaves(path string, n *node, fn func(fullPath string, leaf *node)) {
+	path += n.path
+	if len(n.handlers) > 0 {
+		fn(path, n)
+	}
+	for _, child := range n.children {
+		walkLeaves(path, child, fn)
+	}
+}
+
 func iterate(path, method string, routes Routes, root *node) Routes {
 	path += root.path
 	if len(root.handlers) > 0 {
@@ -203,7 +471,9 @@ func (centre *Centre) Run(addr ...string) (err error) {
 
 	address := resolveAddress(addr)
 	debugPrint("Listening and serving HTTP on %s\n", address)
-	err = http.ListenAndServe(address, centre)
+	srv := centre.newServer(address)
+	defer centre.removeServer(srv)
+	err = srv.ListenAndServe()
 	return
 }
 
@@ -214,7 +484,9 @@ func (centre *Centre) RunTLS(addr, certFile, keyFile string) (err error) {
 	debugPrint("Listening and serving HTTPS on %s\n", addr)
 	defer func() { debugPrintError(err) }()
 
-	err = http.ListenAndServeTLS(addr, certFile, keyFile, centre)
+	srv := centre.newServer(addr)
+	defer centre.removeServer(srv)
+	err = srv.ListenAndServeTLS(certFile, keyFile)
 	return
 }
 
@@ -232,7 +504,9 @@ func (centre *Centre) RunUnix(file string) (err error) {
 	defer listener.Close()
 	defer os.Remove(file)
 
-	err = http.Serve(listener, centre)
+	srv := centre.newServer("")
+	defer centre.removeServer(srv)
+	err = srv.Serve(listener)
 	return
 }
 
@@ -257,20 +531,100 @@ func (centre *Centre) RunFd(fd int) (err error) {
 func (centre *Centre) RunListener(listener net.Listener) (err error) {
 	debugPrint("Listening and serving HTTP on listener what's bind with address@%s", listener.Addr())
 	defer func() { debugPrintError(err) }()
-	err = http.Serve(listener, centre)
+	srv := centre.newServer("")
+	defer centre.removeServer(srv)
+	err = srv.Serve(listener)
 	return
 }
 
+// newServer 创建服务centre的http.Server并记录,以便Shutdown关闭.
+// Run*在服务器停止服务后通过removeServer移除记录.
+func (centre *Centre) newServer(addr string) *http.Server {
+	srv := &http.Server{Addr: addr, Handler: centre}
+	centre.serversMu.Lock()
+	centre.servers = append(centre.servers, srv)
+	centre.serversMu.Unlock()
+	return srv
+}
+
+// removeServer 从记录中移除srv(已被Shutdown移除时不做任何事).
+func (centre *Centre) removeServer(srv *http.Server) {
+	centre.serversMu.Lock()
+	defer centre.serversMu.Unlock()
+	for i, s := range centre.servers {
+		if s == srv {
+			centre.servers = append(centre.servers[:i], centre.servers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Shutdown 优雅地关闭所有由Run*启动的服务器:停止接受新连接,并等待处理中的请求完成或ctx结束.
+// 关闭后Run*返回http.ErrServerClosed.
+func (centre *Centre) Shutdown(ctx context.Context) error {
+	centre.serversMu.Lock()
+	servers := centre.servers
+	centre.servers = nil
+	centre.serversMu.Unlock()
+
+	var err error
+	for _, srv := range servers {
+		if e := srv.Shutdown(ctx); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// ShutdownWithTimeout 同Shutdown,但最多等待d,超时后强制关闭仍未结束的连接并返回context.DeadlineExceeded.
+func (centre *Centre) ShutdownWithTimeout(d time.Duration) error {
+	centre.serversMu.Lock()
+	servers := append([]*http.Server(nil), centre.servers...)
+	centre.serversMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	err := centre.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		for _, srv := range servers {
+			srv.Close() // nolint: errcheck
+		}
+	}
+	return err
+}
+
 // ServeHTTP 遵从 http.Handler 接口.
 func (centre *Centre) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	c := centre.pool.Get().(*Context)
+	var c *Context
+	if centre.DisableContextPool {
+		c = centre.allocateContext()
+	} else {
+		c = centre.pool.Get().(*Context)
+		atomic.AddUint64(&centre.poolStats.Gets, 1)
+	}
 	c.writermem.reset(w)
 	c.Request = req
 	c.reset()
 
+	var endTrace func()
+	if centre.TracerHook != nil && centre.TracerHook.Start != nil {
+		endTrace = centre.TracerHook.Start(c)
+	}
+	for _, fn := range centre.onRequestStart {
+		fn(c)
+	}
 	centre.handleHTTPRequest(c)
+	for _, fn := range centre.onRequestEnd {
+		fn(c)
+	}
+	if endTrace != nil {
+		endTrace()
+	}
 
-	centre.pool.Put(c)
+	if !centre.DisableContextPool {
+		centre.pool.Put(c)
+		atomic.AddUint64(&centre.poolStats.Puts, 1)
+	}
 }
 
 // HandleContext 重新输入已重写的上下文.
@@ -285,18 +639,32 @@ func (centre *Centre) HandleContext(c *Context) {
 }
 
 func (centre *Centre) handleHTTPRequest(c *Context) {
+	if centre.MaxURILength > 0 && len(requestURI(c.Request)) > centre.MaxURILength {
+		c.handlers = centre.Handlers
+		serveError(c, http.StatusRequestURITooLong, MIMEPlain, default414Body)
+		return
+	}
+
+	if centre.NormalizeMethod {
+		c.Request.Method = strings.ToUpper(c.Request.Method)
+	}
 	httpMethod := c.Request.Method
 	rPath := c.Request.URL.Path
-	unescape := false
+	escaped := false
 	if centre.UseRawPath && len(c.Request.URL.RawPath) > 0 {
 		rPath = c.Request.URL.RawPath
-		unescape = centre.UnescapePathValues
+		escaped = true
 	}
+	unescape := centre.UnescapePathValues
 
 	if centre.RemoveExtraSlash {
 		rPath = cleanPath(rPath)
 	}
 
+	if len(centre.hosts) > 0 && centre.serveHost(c, rPath, escaped, unescape) {
+		return
+	}
+
 	// 为给定的HTTP方法查找数的根节点
 	t := centre.trees
 	for i, tl := 0, len(t); i < tl; i++ {
@@ -305,21 +673,33 @@ func (centre *Centre) handleHTTPRequest(c *Context) {
 		}
 		root := t[i].root
 		// 在树中查找路由
-		value := root.getValue(rPath, c.Params, unescape)
+		value := root.getValue(rPath, c.Params, escaped, unescape)
 		if value.handlers != nil {
-			c.handlers = value.handlers
-			c.Params = value.params
-			c.fullPath = value.fullPath
-			c.Next()
-			c.writermem.WriteHeaderNow()
+			serveRoute(c, value)
 			return
 		}
-		if httpMethod != "CONNECT" && rPath != "/" {
-			if value.tsr && centre.RedirectTrailingSlash {
-				redirectTrailingSlash(c)
-				return
+		if centre.CaseInsensitive {
+			// 参数值保留请求中的原始大小写
+			if ciPath, found := root.findCaseInsensitivePath(rPath, false); found {
+				if value := root.getValue(string(ciPath), c.Params, escaped, unescape); value.handlers != nil {
+					serveRoute(c, value)
+					return
+				}
+			}
+		}
+		if httpMethod != "CONNECT" && rPath != "/" && value.tsr {
+			switch centre.TrailingSlashPolicy {
+			case TrailingSlashRedirect:
+				if centre.RedirectTrailingSlash {
+					redirectTrailingSlash(c)
+					return
+				}
+			case TrailingSlashIgnore:
+				if value := root.getValue(toggleTrailingSlash(rPath), c.Params, escaped, unescape); value.handlers != nil {
+					serveRoute(c, value)
+					return
+				}
 			}
-			return
 			// if centre.RedirectFixedPath && redirectFixedPath(c, root, centre.RedirectFixedPath) {
 			// 	return
 			// }
@@ -327,33 +707,68 @@ func (centre *Centre) handleHTTPRequest(c *Context) {
 		break
 	}
 
-	if centre.HandleMethodNotAllowed {
-		for _, tree := range centre.trees {
-			if tree.method == httpMethod {
-				continue
-			}
-			if value := tree.root.getValue(rPath, nil, unescape); value.handlers != nil {
-				c.handlers = centre.allNoMethod
-				serveError(c, http.StatusMethodNotAllowed, default405Body)
+	if httpMethod == http.MethodHead && centre.AutoHEAD {
+		if root := t.get(http.MethodGet); root != nil {
+			if value := root.getValue(rPath, c.Params, escaped, unescape); value.handlers != nil {
+				serveRoute(c, value)
 				return
 			}
 		}
 	}
+
+	if centre.HandleMethodNotAllowed {
+		if allowed := centre.AllowedMethods(rPath); len(allowed) > 0 {
+			c.handlers = centre.allNoMethod
+			c.writermem.Header().Set("Allow", strings.Join(allowed, ", "))
+			serveError(c, http.StatusMethodNotAllowed, centre.MethodNotAllowedContentType, centre.MethodNotAllowedBody)
+			return
+		}
+	}
 	c.handlers = centre.allNoRoute
-	serveError(c, http.StatusNotFound, default404Body)
+	serveError(c, http.StatusNotFound, centre.NotFoundContentType, centre.NotFoundBody)
+}
+
+// serveRoute 执行匹配到的路由的处理程序链,HEAD请求使用serveHead.
+func serveRoute(c *Context, value nodeValue) {
+	c.handlers = value.handlers
+	c.Params = value.params
+	c.fullPath = value.fullPath
+	if c.Request.Method == http.MethodHead {
+		serveHead(c)
+		return
+	}
+	c.Next()
+	c.writermem.WriteHeaderNow()
+}
+
+// toggleTrailingSlash 去掉path结尾的'/',没有时则添加.
+func toggleTrailingSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path[:len(path)-1]
+	}
+	return path + "/"
 }
 
-var mimePlain = []string{MIMEPlain}
+// serveHead 执行处理程序链,丢弃写入的响应体,但保留headers并按丢弃的字节数设置Content-Length.
+func serveHead(c *Context) {
+	hw := newHeadWriter(c.writermem.ResponseWriter)
+	c.writermem.ResponseWriter = hw
+	c.Next()
+	c.writermem.WriteHeaderNow()
+	hw.finish()
+}
 
-func serveError(c *Context, code int, defaultMessage []byte) {
+func serveError(c *Context, code int, contentType string, body []byte) {
 	c.writermem.status = code
 	c.Next()
 	if c.writermem.Written() {
 		return
 	}
 	if c.writermem.Status() == code {
-		c.writermem.Header()["Content-Type"] = mimePlain
-		_, err := c.Writer.Write(defaultMessage)
+		if contentType != "" {
+			c.writermem.Header()["Content-Type"] = []string{contentType}
+		}
+		_, err := c.Writer.Write(body)
 		if err != nil {
 			debugPrint("cannot write message to writer during serve error: %v", err)
 		}
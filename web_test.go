@@ -0,0 +1,685 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCentreAllowedMethods(t *testing.T) {
+	r := New()
+	noop := func(c *Context) {}
+	r.GET("/users/:id", noop)
+	r.PUT("/users/:id", noop)
+	r.DELETE("/users/:id", noop)
+	r.POST("/login", noop)
+
+	tests := []struct {
+		path     string
+		autoHEAD bool
+		want     []string
+	}{
+		{"/users/:id", false, []string{"DELETE", "GET", "PUT"}},
+		{"/users/42", true, []string{"DELETE", "GET", "HEAD", "PUT"}},
+		{"/login", true, []string{"POST"}},
+		{"/missing", true, nil},
+	}
+	for _, tt := range tests {
+		r.AutoHEAD = tt.autoHEAD
+		if got := r.AllowedMethods(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AllowedMethods(%q) AutoHEAD=%v = %v, want %v", tt.path, tt.autoHEAD, got, tt.want)
+		}
+	}
+
+	r.OPTIONS("/*path", noop)
+	if got, want := r.AllowedMethods("/login"), []string{"OPTIONS", "POST"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AllowedMethods with OPTIONS route = %v, want %v", got, want)
+	}
+}
+
+func TestCentreMethodNotAllowedAllowHeader(t *testing.T) {
+	r := New()
+	r.HandleMethodNotAllowed = true
+	r.AutoHEAD = true
+	r.GET("/items", func(c *Context) {})
+	r.POST("/items", func(c *Context) {})
+	r.OPTIONS("/items", func(c *Context) {})
+
+	w := performRequest(r, http.MethodDelete, "/items")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE /items status = %d", w.Code)
+	}
+	if got, want := w.Header().Get("Allow"), "GET, HEAD, OPTIONS, POST"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+}
+
+func TestCentreRouteManifestHandler(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", Named("getUser", func(c *Context) {}))
+	r.POST("/users", Named("createUser", func(c *Context) {}))
+	r.GET("/_routes", r.RouteManifestHandler())
+
+	w := performRequest(r, http.MethodGet, "/_routes")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /_routes status = %d", w.Code)
+	}
+	var manifest []RouteManifestEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &manifest); err != nil {
+		t.Fatalf("decode manifest: %v (%s)", err, w.Body.String())
+	}
+	want := []RouteManifestEntry{
+		{Method: "POST", Path: "/users", Handler: "createUser"},
+		{Method: "GET", Path: "/users/:id", Handler: "getUser"},
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("manifest = %+v, want %+v", manifest, want)
+	}
+}
+
+func TestCentreRouteUnescapeOption(t *testing.T) {
+	r := New()
+	r.UseRawPath = true
+	r.UnescapePathValues = true
+	param := func(c *Context) { c.String(http.StatusOK, c.Param("filepath")) }
+	r.HandleWithOptions(http.MethodGet, "/raw/*filepath", []RouteOption{WithUnescape(false)}, param)
+	r.GET("/files/*filepath", param)
+
+	if w := performRequest(r, http.MethodGet, "/raw/a%2Fb/c%20d"); w.Body.String() != "/a%2Fb/c%20d" {
+		t.Errorf("raw route param = %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/files/a%2Fb/c%20d"); w.Body.String() != "/a/b/c d" {
+		t.Errorf("unescaped route param = %q", w.Body.String())
+	}
+
+	r.UnescapePathValues = false
+	r.HandleWithOptions(http.MethodGet, "/decoded/*filepath", []RouteOption{WithUnescape(true)}, param)
+	if w := performRequest(r, http.MethodGet, "/decoded/a%2Fb"); w.Body.String() != "/a/b" {
+		t.Errorf("WithUnescape(true) param = %q", w.Body.String())
+	}
+	if w := performRequest(r, http.MethodGet, "/files/a%2Fb"); w.Body.String() != "/a%2Fb" {
+		t.Errorf("global UnescapePathValues=false param = %q", w.Body.String())
+	}
+}
+
+func TestCentreLoadHTMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/hello.tmpl": {Data: []byte(`<p>[[ upper .name ]]</p>`)},
+		"views/other.txt":  {Data: []byte(`ignored`)},
+	}
+	r := New()
+	r.Delims("[[", "]]")
+	r.SetFuncMap(template.FuncMap{"upper": strings.ToUpper})
+	r.LoadHTMLFS(fsys, "views/*.tmpl")
+	r.GET("/", func(c *Context) { c.HTML(http.StatusOK, "hello.tmpl", Data{"name": "web"}) })
+
+	w := performRequest(r, http.MethodGet, "/")
+	if w.Code != http.StatusOK || w.Body.String() != "<p>WEB</p>" {
+		t.Errorf("rendered %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestCentreCustomErrorBodies(t *testing.T) {
+	r := New()
+	r.HandleMethodNotAllowed = true
+	r.GET("/items", func(c *Context) {})
+
+	if w := performRequest(r, http.MethodGet, "/missing"); w.Code != http.StatusNotFound ||
+		w.Body.String() != "404 page not found" || w.Header().Get("Content-Type") != MIMEPlain {
+		t.Errorf("default 404 = %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+
+	r.NotFoundBody = []byte(`{"error":"not found"}`)
+	r.NotFoundContentType = MIMEJSON
+	r.MethodNotAllowedBody = []byte(`{"error":"method not allowed"}`)
+	r.MethodNotAllowedContentType = MIMEJSON
+
+	w := performRequest(r, http.MethodGet, "/missing")
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"error":"not found"}` || w.Header().Get("Content-Type") != MIMEJSON {
+		t.Errorf("custom 404 = %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	w = performRequest(r, http.MethodPost, "/items")
+	if w.Code != http.StatusMethodNotAllowed || w.Body.String() != `{"error":"method not allowed"}` || w.Header().Get("Content-Type") != MIMEJSON {
+		t.Errorf("custom 405 = %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
+
+func TestOnRouteRegister(t *testing.T) {
+	type registered struct{ method, path, name string }
+	var got []registered
+	r := New()
+	r.OnRouteRegister = func(method, path, handlerName string) {
+		got = append(got, registered{method, path, handlerName})
+	}
+	noop := func(c *Context) {}
+	r.GET("/users", Named("listUsers", noop))
+	api := r.Board("/api")
+	api.POST("/items/:id", Named("createItem", noop))
+	api.DELETE("/items/:id", Named("deleteItem", noop))
+
+	want := []registered{
+		{http.MethodGet, "/users", "listUsers"},
+		{http.MethodPost, "/api/items/:id", "createItem"},
+		{http.MethodDelete, "/api/items/:id", "deleteItem"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OnRouteRegister calls = %v, want %v", got, want)
+	}
+}
+
+func TestOnRouteRegisterReleaseMode(t *testing.T) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+	calls := 0
+	r := New()
+	r.OnRouteRegister = func(method, path, handlerName string) { calls++ }
+	r.GET("/", func(c *Context) {})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestCanHandle(t *testing.T) {
+	r := New()
+	r.GET("/users/:id", func(c *Context) {})
+	r.GET("/static/*filepath", func(c *Context) {})
+
+	tests := []struct {
+		method, path string
+		conflict     bool
+	}{
+		{http.MethodGet, "/users/:name", true},      // 通配参数名冲突
+		{http.MethodGet, "/static/:file", true},     // 与通配冲突
+		{http.MethodGet, "/users/:id", true},        // 重复路径
+		{http.MethodGet, "/users/:id/posts", false}, // 无冲突
+		{http.MethodPost, "/users/:name", false},    // 不同的method
+		{http.MethodGet, "users", true},             // 无效路径
+		{"", "/users", true},                        // 空method
+	}
+	for _, tt := range tests {
+		err := r.CanHandle(tt.method, tt.path)
+		if (err != nil) != tt.conflict {
+			t.Errorf("CanHandle(%q, %q) = %v, want conflict %v", tt.method, tt.path, err, tt.conflict)
+		}
+	}
+
+	if n := len(r.Routes()); n != 2 {
+		t.Errorf("len(Routes()) = %d after CanHandle, want 2", n)
+	}
+	if w := performRequest(r, http.MethodGet, "/users/1/posts"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /users/1/posts = %d, want 404 (CanHandle must not register)", w.Code)
+	}
+	if w := performRequest(r, http.MethodGet, "/users/1"); w.Code != http.StatusOK {
+		t.Errorf("GET /users/1 = %d, want 200", w.Code)
+	}
+}
+
+func TestAutoHEAD(t *testing.T) {
+	handler := func(c *Context) {
+		c.Header("X-Route", "get")
+		c.String(http.StatusOK, "hello world")
+	}
+
+	r := New()
+	r.GET("/doc", handler)
+	if w := performRequest(r, http.MethodHead, "/doc"); w.Code != http.StatusNotFound {
+		t.Errorf("AutoHEAD off: HEAD /doc = %d, want 404", w.Code)
+	}
+
+	r = New()
+	r.AutoHEAD = true
+	r.GET("/doc", handler)
+	r.GET("/explicit", handler)
+	r.HEAD("/explicit", func(c *Context) { c.Header("X-Route", "head") })
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	resp, err := http.Head(server.URL + "/doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(body) != 0 {
+		t.Errorf("HEAD /doc = %d, body %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Route") != "get" || resp.Header.Get("Content-Length") != "11" {
+		t.Errorf("HEAD /doc headers = %v", resp.Header)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("HEAD /doc Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	if w := performRequest(r, http.MethodHead, "/explicit"); w.Header().Get("X-Route") != "head" {
+		t.Errorf("HEAD /explicit X-Route = %q, want the explicit HEAD handler", w.Header().Get("X-Route"))
+	}
+	if w := performRequest(r, http.MethodGet, "/doc"); w.Body.String() != "hello world" {
+		t.Errorf("GET /doc body = %q", w.Body.String())
+	}
+}
+
+func TestMaxURILength(t *testing.T) {
+	calls := 0
+	r := New()
+	r.MaxURILength = 20
+	r.GET("/items", func(c *Context) {
+		calls++
+		c.Status(http.StatusOK)
+	})
+
+	atLimit := "/items?q=" + strings.Repeat("a", 11) // 20个字节
+	if w := performRequest(r, http.MethodGet, atLimit); w.Code != http.StatusOK {
+		t.Errorf("GET %s (%d bytes) = %d, want 200", atLimit, len(atLimit), w.Code)
+	}
+	over := atLimit + "a"
+	if w := performRequest(r, http.MethodGet, over); w.Code != http.StatusRequestURITooLong {
+		t.Errorf("GET %s (%d bytes) = %d, want 414", over, len(over), w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("handler calls = %d, want 1", calls)
+	}
+
+	r.MaxURILength = 0
+	if w := performRequest(r, http.MethodGet, "/items?q="+strings.Repeat("a", 4096)); w.Code != http.StatusOK {
+		t.Errorf("unlimited: status = %d, want 200", w.Code)
+	}
+}
+
+func TestTrailingSlashPolicy(t *testing.T) {
+	tests := []struct {
+		policy        TrailingSlashPolicy
+		wantNoSlash   int // GET /foo (注册的是/foo/)
+		wantWithSlash int // GET /bar/ (注册的是/bar)
+	}{
+		{TrailingSlashRedirect, http.StatusMovedPermanently, http.StatusMovedPermanently},
+		{TrailingSlashStrict, http.StatusNotFound, http.StatusNotFound},
+		{TrailingSlashIgnore, http.StatusOK, http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := New()
+		r.TrailingSlashPolicy = tt.policy
+		r.GET("/foo/", func(c *Context) { c.String(http.StatusOK, "foo") })
+		r.GET("/bar", func(c *Context) { c.String(http.StatusOK, "bar") })
+
+		w := performRequest(r, http.MethodGet, "/foo")
+		if w.Code != tt.wantNoSlash {
+			t.Errorf("policy %d: GET /foo = %d, want %d", tt.policy, w.Code, tt.wantNoSlash)
+		}
+		if tt.policy == TrailingSlashRedirect && w.Header().Get("Location") != "/foo/" {
+			t.Errorf("policy %d: GET /foo Location = %q, want /foo/", tt.policy, w.Header().Get("Location"))
+		}
+		if tt.policy == TrailingSlashIgnore && w.Body.String() != "foo" {
+			t.Errorf("policy %d: GET /foo body = %q, want foo", tt.policy, w.Body.String())
+		}
+
+		w = performRequest(r, http.MethodGet, "/bar/")
+		if w.Code != tt.wantWithSlash {
+			t.Errorf("policy %d: GET /bar/ = %d, want %d", tt.policy, w.Code, tt.wantWithSlash)
+		}
+		if tt.policy == TrailingSlashRedirect && w.Header().Get("Location") != "/bar" {
+			t.Errorf("policy %d: GET /bar/ Location = %q, want /bar", tt.policy, w.Header().Get("Location"))
+		}
+
+		if w := performRequest(r, http.MethodGet, "/foo/"); w.Code != http.StatusOK {
+			t.Errorf("policy %d: GET /foo/ = %d, want 200", tt.policy, w.Code)
+		}
+	}
+
+	r := New()
+	r.RedirectTrailingSlash = false
+	r.GET("/foo/", func(c *Context) {})
+	if w := performRequest(r, http.MethodGet, "/foo"); w.Code != http.StatusNotFound {
+		t.Errorf("redirect disabled: GET /foo = %d, want 404", w.Code)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	r := New()
+	r.CaseInsensitive = true
+	r.GET("/users", func(c *Context) { c.String(http.StatusOK, "list") })
+	r.GET("/users/:name/Posts", func(c *Context) { c.String(http.StatusOK, c.Param("name")) })
+	r.GET("/files/*path", func(c *Context) { c.String(http.StatusOK, c.Param("path")) })
+
+	tests := []struct {
+		path, want string
+	}{
+		{"/users", "list"},
+		{"/Users", "list"},
+		{"/USERS", "list"},
+		{"/users/Alice/posts", "Alice"}, // 参数值保留原始大小写
+		{"/USERS/BoB/POSTS", "BoB"},
+		{"/Files/Docs/README.md", "/Docs/README.md"},
+	}
+	for _, tt := range tests {
+		w := performRequest(r, http.MethodGet, tt.path)
+		if w.Code != http.StatusOK || w.Body.String() != tt.want {
+			t.Errorf("GET %s = %d %q, want 200 %q", tt.path, w.Code, w.Body.String(), tt.want)
+		}
+	}
+
+	r.CaseInsensitive = false
+	if w := performRequest(r, http.MethodGet, "/Users"); w.Code != http.StatusNotFound {
+		t.Errorf("CaseInsensitive off: GET /Users = %d, want 404", w.Code)
+	}
+}
+
+func TestOnRequestStartEnd(t *testing.T) {
+	var events []string
+	r := New()
+	r.HandleMethodNotAllowed = true
+	r.OnRequestStart(func(c *Context) { events = append(events, "start "+c.Request.URL.Path) })
+	r.OnRequestEnd(func(c *Context) {
+		events = append(events, "end "+c.Request.URL.Path+" "+http.StatusText(c.Writer.Status()))
+	})
+	r.Use(func(c *Context) {
+		if c.Query("deny") != "" {
+			c.AbortWithStatus(http.StatusForbidden)
+		}
+	})
+	r.GET("/ok", func(c *Context) {
+		events = append(events, "handler")
+		c.Status(http.StatusOK)
+	})
+
+	performRequest(r, http.MethodGet, "/ok")
+	performRequest(r, http.MethodGet, "/missing")
+	performRequest(r, http.MethodPost, "/ok")
+	performRequest(r, http.MethodGet, "/ok?deny=1")
+
+	want := []string{
+		"start /ok", "handler", "end /ok OK",
+		"start /missing", "end /missing Not Found",
+		"start /ok", "end /ok Method Not Allowed",
+		"start /ok", "end /ok Forbidden",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q\nwant     %q", events, want)
+	}
+}
+
+func TestShutdownWithTimeoutForcesClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	r := New()
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		<-release
+	})
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- r.RunListener(listener) }()
+	clientErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		clientErr <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow handler did not start")
+	}
+	begin := time.Now()
+	if err := r.ShutdownWithTimeout(50 * time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ShutdownWithTimeout = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 2*time.Second {
+		t.Errorf("ShutdownWithTimeout took %v", elapsed)
+	}
+
+	select {
+	case err := <-clientErr:
+		if err == nil {
+			t.Error("client got a response, want the connection force-closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client connection was not closed")
+	}
+	if err := <-runErr; err != http.ErrServerClosed {
+		t.Errorf("RunListener = %v, want http.ErrServerClosed", err)
+	}
+}
+
+func TestRunListenerForgetsStoppedServers(t *testing.T) {
+	r := New()
+	for i := 0; i < 3; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		listener.Close()
+		if err := r.RunListener(listener); err == nil {
+			t.Fatal("RunListener on a closed listener = nil")
+		}
+	}
+	r.serversMu.Lock()
+	n := len(r.servers)
+	r.serversMu.Unlock()
+	if n != 0 {
+		t.Errorf("%d servers still tracked after RunListener returned", n)
+	}
+}
+
+func TestRemoveRoute(t *testing.T) {
+	r := New()
+	text := func(s string) HandlerFunc { return func(c *Context) { c.String(http.StatusOK, s+c.Param("id")) } }
+	r.GET("/users", text("list"))
+	r.GET("/users/:id", text("user "))
+	r.GET("/user-groups", text("groups"))
+	r.POST("/users", text("create"))
+
+	if !r.RemoveRoute(http.MethodGet, "/users") {
+		t.Fatal("RemoveRoute(GET /users) = false")
+	}
+	if w := performRequest(r, http.MethodGet, "/users"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /users after removal = %d, want 404", w.Code)
+	}
+	for path, want := range map[string]string{"/users/7": "user 7", "/user-groups": "groups"} {
+		if w := performRequest(r, http.MethodGet, path); w.Code != http.StatusOK || w.Body.String() != want {
+			t.Errorf("GET %s = %d %q, want 200 %q", path, w.Code, w.Body.String(), want)
+		}
+	}
+	if w := performRequest(r, http.MethodPost, "/users"); w.Body.String() != "create" {
+		t.Errorf("POST /users = %d %q, other methods must be untouched", w.Code, w.Body.String())
+	}
+
+	if r.RemoveRoute(http.MethodGet, "/users") || r.RemoveRoute(http.MethodGet, "/users/:name") || r.RemoveRoute(http.MethodPut, "/users") {
+		t.Error("RemoveRoute of an unregistered route = true")
+	}
+
+	r.GET("/users", text("again"))
+	if w := performRequest(r, http.MethodGet, "/users"); w.Body.String() != "again" {
+		t.Errorf("re-registered GET /users = %q", w.Body.String())
+	}
+
+	if !r.RemoveRoute(http.MethodPost, "/users") {
+		t.Fatal("RemoveRoute(POST /users) = false")
+	}
+	for _, route := range r.Routes() {
+		if route.Method == http.MethodPost {
+			t.Errorf("Routes() still contains %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) { c.Status(http.StatusOK) })
+	if s := r.PoolStats(); s != (PoolStats{}) {
+		t.Errorf("initial PoolStats = %+v, want zero", s)
+	}
+
+	const concurrent = 32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < concurrent; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			performRequest(r, http.MethodGet, "/")
+		}()
+	}
+	close(start)
+	wg.Wait()
+	warm := r.PoolStats()
+	if warm.Gets != concurrent || warm.Puts != concurrent {
+		t.Errorf("after burst: %+v, want %d gets and puts", warm, concurrent)
+	}
+	if warm.News == 0 || warm.News > warm.Gets {
+		t.Errorf("after burst: News = %d, want 1..%d", warm.News, warm.Gets)
+	}
+
+	const sequential = 1000
+	for i := 0; i < sequential; i++ {
+		performRequest(r, http.MethodGet, "/")
+	}
+	s := r.PoolStats()
+	if s.Gets != warm.Gets+sequential || s.Puts != s.Gets {
+		t.Errorf("after sequential load: %+v", s)
+	}
+	// 池已预热,顺序请求应复用上下文(竞态检测模式下sync.Pool会随机丢弃部分对象)
+	if grown := s.News - warm.News; grown > sequential/2 {
+		t.Errorf("News grew by %d over %d sequential requests, want the pool to be reused", grown, sequential)
+	}
+}
+
+func TestDisableContextPool(t *testing.T) {
+	r := New()
+	r.DisableContextPool = true
+	seen := map[*Context]bool{}
+	r.GET("/", func(c *Context) {
+		if seen[c] {
+			t.Errorf("context %p reused", c)
+		}
+		seen[c] = true
+	})
+
+	const requests = 100
+	for i := 0; i < requests; i++ {
+		performRequest(r, http.MethodGet, "/")
+	}
+	if len(seen) != requests {
+		t.Errorf("distinct contexts = %d, want %d", len(seen), requests)
+	}
+	if s := r.PoolStats(); s.Gets != 0 || s.Puts != 0 || s.News != requests {
+		t.Errorf("PoolStats = %+v, want %d news and no pool traffic", s, requests)
+	}
+}
+
+func TestUseWhen(t *testing.T) {
+	var ran []string
+	auth := func(c *Context) {
+		ran = append(ran, "auth")
+		if c.GetHeader("Authorization") == "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+		}
+	}
+	audit := func(c *Context) { ran = append(ran, "audit") }
+
+	r := New()
+	r.UseWhen(func(c *Context) bool { return !strings.HasPrefix(c.Request.URL.Path, "/public") }, auth, audit)
+	r.GET("/private", func(c *Context) { ran = append(ran, "private") })
+	r.GET("/public/info", func(c *Context) { ran = append(ran, "public") })
+
+	if w := performRequest(r, http.MethodGet, "/public/info"); w.Code != http.StatusOK {
+		t.Errorf("GET /public/info = %d, want 200", w.Code)
+	}
+	if want := []string{"public"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("public: ran %q, want %q", ran, want)
+	}
+
+	ran = nil
+	if w := performRequest(r, http.MethodGet, "/private"); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /private = %d, want 401", w.Code)
+	}
+	if want := []string{"auth"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("private: ran %q, want %q", ran, want)
+	}
+
+	ran = nil
+	req := httptest.NewRequest(http.MethodGet, "/private", nil)
+	req.Header.Set("Authorization", "token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if want := []string{"auth", "audit", "private"}; w.Code != http.StatusOK || !reflect.DeepEqual(ran, want) {
+		t.Errorf("authorized private: %d, ran %q, want %q", w.Code, ran, want)
+	}
+
+	ran = nil
+	board := New()
+	admin := board.Board("/admin")
+	admin.UseWhen(func(c *Context) bool { return c.Request.Method != http.MethodGet }, audit)
+	admin.GET("/", func(c *Context) {})
+	admin.POST("/", func(c *Context) {})
+	performRequest(board, http.MethodGet, "/admin/")
+	performRequest(board, http.MethodPost, "/admin/")
+	if want := []string{"audit"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("board UseWhen: ran %q, want %q", ran, want)
+	}
+}
+
+func TestNormalizeMethod(t *testing.T) {
+	r := New()
+	r.GET("/items", func(c *Context) { c.String(http.StatusOK, c.Request.Method) })
+
+	if w := performRequest(r, "get", "/items"); w.Code != http.StatusNotFound {
+		t.Errorf("NormalizeMethod off: get /items = %d, want 404", w.Code)
+	}
+
+	r.NormalizeMethod = true
+	for _, method := range []string{"get", "Get", "GET"} {
+		w := performRequest(r, method, "/items")
+		if w.Code != http.StatusOK || w.Body.String() != http.MethodGet {
+			t.Errorf("NormalizeMethod on: %s /items = %d %q, want 200 GET", method, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestTracerHook(t *testing.T) {
+	type span struct{ startPath, name string }
+	var spans []span
+	r := New()
+	r.TracerHook = &TracerHook{Start: func(c *Context) func() {
+		s := span{startPath: c.Request.URL.Path}
+		return func() {
+			s.name = c.FullPath()
+			spans = append(spans, s)
+		}
+	}}
+	r.GET("/users/:id", func(c *Context) {})
+
+	performRequest(r, http.MethodGet, "/users/42")
+	performRequest(r, http.MethodGet, "/missing")
+	want := []span{{"/users/42", "/users/:id"}, {"/missing", ""}}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("spans = %+v, want %+v", spans, want)
+	}
+
+	r.TracerHook = &TracerHook{Start: func(c *Context) func() { return nil }}
+	if w := performRequest(r, http.MethodGet, "/users/1"); w.Code != http.StatusOK {
+		t.Errorf("nil end func: status = %d", w.Code)
+	}
+	r.TracerHook = &TracerHook{}
+	if w := performRequest(r, http.MethodGet, "/users/1"); w.Code != http.StatusOK {
+		t.Errorf("nil Start: status = %d", w.Code)
+	}
+}